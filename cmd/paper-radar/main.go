@@ -4,11 +4,14 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/kyc001/paper-radar/internal/app"
+	"github.com/kyc001/paper-radar/internal/cache"
 	"github.com/kyc001/paper-radar/internal/config"
 	"github.com/kyc001/paper-radar/internal/notify"
 )
@@ -28,6 +31,12 @@ func main() {
 		runDigest(os.Args[2:])
 	case "run":
 		runAll(ctx, os.Args[2:])
+	case "feedback":
+		runFeedback(os.Args[2:])
+	case "tune":
+		runTune(os.Args[2:])
+	case "cache":
+		runCache(os.Args[2:])
 	default:
 		printUsage()
 		os.Exit(2)
@@ -39,8 +48,10 @@ func runFetch(ctx context.Context, args []string) {
 	configPath := fs.String("config", "config.yaml", "Path to YAML config file")
 	statePath := fs.String("state", app.DefaultStatePath, "Path to JSON state file")
 	maxResults := fs.Int("max-results", 0, "Override max results per topic")
-	minScore := fs.Int("min-score", 1, "Override minimum score threshold")
+	minScore := fs.Float64("min-score", 1, "Override minimum score threshold")
 	withKimi := fs.Bool("with-kimi", false, "Enable papers.cool Kimi summary enrichment")
+	kimiTop := fs.Int("kimi-top", 0, "Only fetch Kimi summaries for the top N pending papers (0 means every newly queued paper)")
+	maxFailed := fs.Float64("max-failed", defaultMaxFailed, "Exit non-zero when more than this fraction of topics fail to fetch (0 means any)")
 	if err := fs.Parse(args); err != nil {
 		fmt.Fprintf(os.Stderr, "fetch: %v\n", err)
 		os.Exit(2)
@@ -52,13 +63,22 @@ func runFetch(ctx context.Context, args []string) {
 		MaxResults: *maxResults,
 		MinScore:   *minScore,
 		WithKimi:   *withKimi,
+		KimiTop:    *kimiTop,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "fetch failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("fetched=%d queued=%d topics=%d\n", result.Fetched, result.Queued, result.Topics)
+	printTopicStatuses(os.Stdout, result.Statuses)
+	fmt.Printf("fetched=%d queued=%d vetoed=%d enriched=%d cited=%d topics=%d failed=%d\n", result.Fetched, result.Queued, result.Vetoed, result.Enriched, result.Cited, result.Topics, result.Failed())
+	if result.CitationErr != nil {
+		fmt.Fprintf(os.Stderr, "fetch: citation lookup failed: %v\n", result.CitationErr)
+	}
+	if result.FailedFraction() > *maxFailed {
+		fmt.Fprintf(os.Stderr, "fetch failed: %d of %d topics failed\n", result.Failed(), result.Topics)
+		os.Exit(1)
+	}
 }
 
 func runDigest(args []string) {
@@ -97,18 +117,19 @@ func runAll(ctx context.Context, args []string) {
 	outputDir := fs.String("out", "outputs", "Output directory for markdown digest")
 	dateStr := fs.String("date", "", "Digest date (YYYY-MM-DD), defaults to today")
 	maxResults := fs.Int("max-results", 0, "Override max results per topic")
-	minScore := fs.Int("min-score", 1, "Override minimum score threshold")
+	minScore := fs.Float64("min-score", 1, "Override minimum score threshold")
 	topN := fs.Int("top", 0, "Only emit top N papers in this digest (0 means all)")
 	withKimi := fs.Bool("with-kimi", false, "Enable papers.cool Kimi summary enrichment")
 	feishuWebhook := fs.String("feishu-webhook", "", "Feishu bot webhook URL for digest notification")
 	notifyMaxChars := fs.Int("notify-max-chars", 2800, "Max characters per Feishu message chunk")
+	maxFailed := fs.Float64("max-failed", defaultMaxFailed, "Stop with a non-zero exit when more than this fraction of topics fail to fetch (0 means any)")
 	asPDF := fs.Bool("pdf", false, "Generate PDF output via headless Chrome")
 	if err := fs.Parse(args); err != nil {
 		fmt.Fprintf(os.Stderr, "run: %v\n", err)
 		os.Exit(2)
 	}
 
-	cfg, err := config.Load(*configPath)
+	cfg, err := config.Load(*configPath, app.Registry{})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "run failed loading config: %v\n", err)
 		os.Exit(1)
@@ -121,12 +142,21 @@ func runAll(ctx context.Context, args []string) {
 		MaxResults: *maxResults,
 		MinScore:   *minScore,
 		WithKimi:   *withKimi,
+		KimiTop:    *topN,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "run failed in fetch stage: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("fetch: fetched=%d queued=%d topics=%d\n", fetchResult.Fetched, fetchResult.Queued, fetchResult.Topics)
+	printTopicStatuses(os.Stdout, fetchResult.Statuses)
+	fmt.Printf("fetch: fetched=%d queued=%d vetoed=%d enriched=%d cited=%d topics=%d failed=%d\n", fetchResult.Fetched, fetchResult.Queued, fetchResult.Vetoed, fetchResult.Enriched, fetchResult.Cited, fetchResult.Topics, fetchResult.Failed())
+	if fetchResult.CitationErr != nil {
+		fmt.Fprintf(os.Stderr, "run: citation lookup failed: %v\n", fetchResult.CitationErr)
+	}
+	if fetchResult.FailedFraction() > *maxFailed {
+		fmt.Fprintf(os.Stderr, "run failed in fetch stage: %d of %d topics failed\n", fetchResult.Failed(), fetchResult.Topics)
+		os.Exit(1)
+	}
 
 	date := parseDateOrNow(*dateStr)
 	path, count, err := app.RunDigest(app.DigestOptions{
@@ -151,7 +181,7 @@ func runAll(ctx context.Context, args []string) {
 		if content != "" {
 			text += "\n\n" + content
 		}
-		if err := notify.NewFeishuWebhook().SendLongText(ctx, resolvedWebhook, text, *notifyMaxChars); err != nil {
+		if err := notify.NewFeishuWebhook(cfg.HTTPPolicy("feishu")).SendLongText(ctx, resolvedWebhook, text, *notifyMaxChars); err != nil {
 			fmt.Fprintf(os.Stderr, "feishu notify failed: %v\n", err)
 			os.Exit(1)
 		}
@@ -159,6 +189,152 @@ func runAll(ctx context.Context, args []string) {
 	}
 }
 
+func runFeedback(args []string) {
+	fs := flag.NewFlagSet("feedback", flag.ExitOnError)
+	statePath := fs.String("state", app.DefaultStatePath, "Path to JSON state file")
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "feedback: %v\n", err)
+		os.Exit(2)
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: paper-radar feedback [-state path] <id> up|down")
+		os.Exit(2)
+	}
+
+	record, err := app.RunFeedback(app.FeedbackOptions{
+		StatePath: *statePath,
+		ID:        fs.Arg(0),
+		Label:     fs.Arg(1),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "feedback failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("feedback: %s %s topics=%s\n", record.ID, record.Label, strings.Join(record.Topics, ","))
+}
+
+func runTune(args []string) {
+	fs := flag.NewFlagSet("tune", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to YAML config file")
+	statePath := fs.String("state", app.DefaultStatePath, "Path to JSON state file")
+	minLabels := fs.Int("min-labels", 0, "Labelled papers a topic needs before changes are proposed (0 means default)")
+	maxCandidates := fs.Int("max-candidates", 0, "Max new keywords proposed per topic (0 means default)")
+	write := fs.Bool("write", false, "Apply the proposed changes to the config file")
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "tune: %v\n", err)
+		os.Exit(2)
+	}
+
+	result, err := app.RunTune(app.TuneOptions{
+		ConfigPath:    *configPath,
+		StatePath:     *statePath,
+		MinLabels:     *minLabels,
+		MaxCandidates: *maxCandidates,
+		Write:         *write,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tune failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if result.Diff == "" {
+		fmt.Printf("tune: no changes proposed from %d labels\n", result.Labels)
+		return
+	}
+	fmt.Print(result.Diff)
+	if result.Written {
+		fmt.Printf("tune: wrote %s\n", *configPath)
+	} else {
+		fmt.Println("tune: re-run with -write to apply")
+	}
+}
+
+func runCache(args []string) {
+	if len(args) == 0 || (args[0] != "stats" && args[0] != "prune") {
+		fmt.Fprintln(os.Stderr, "usage: paper-radar cache stats|prune [-config config.yaml] [-state path]")
+		os.Exit(2)
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to YAML config file (for the cache limits)")
+	statePath := fs.String("state", app.DefaultStatePath, "Path to JSON state file; the cache lives next to it")
+	if err := fs.Parse(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %v\n", err)
+		os.Exit(2)
+	}
+
+	enrichments, err := app.OpenCache(app.CacheOptions{ConfigPath: *configPath, StatePath: *statePath})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cache failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if action == "prune" {
+		result, err := enrichments.Prune()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cache prune failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("cache: removed %d expired and %d over the size limit, freed %s\n", result.Expired, result.Evicted, formatBytes(result.Bytes))
+		return
+	}
+
+	stats, err := enrichments.Stats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cache stats failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("cache: %s\n", enrichments.Dir())
+	printCacheStats(os.Stdout, stats)
+}
+
+// printCacheStats writes one row per enrichment kind and a total.
+func printCacheStats(w io.Writer, stats []cache.KindStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tENTRIES\tSIZE\tEXPIRED")
+	var total cache.KindStats
+	for _, kind := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\n", kind.Kind, kind.Entries, formatBytes(kind.Bytes), kind.Expired)
+		total.Entries += kind.Entries
+		total.Bytes += kind.Bytes
+		total.Expired += kind.Expired
+	}
+	fmt.Fprintf(tw, "total\t%d\t%s\t%d\n", total.Entries, formatBytes(total.Bytes), total.Expired)
+	tw.Flush()
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// defaultMaxFailed tolerates a minority of topics failing to fetch, e.g. one
+// source being down, while still failing the run when most topics do.
+const defaultMaxFailed = 0.5
+
+// printTopicStatuses writes one row per topic: counts, time taken and the
+// error of a topic that failed.
+func printTopicStatuses(w io.Writer, statuses []app.TopicStatus) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOPIC\tSOURCE\tFETCHED\tQUEUED\tTIME\tSTATUS")
+	for _, status := range statuses {
+		result := "ok"
+		if status.Err != nil {
+			result = "error: " + status.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n", status.Name, status.Source, status.Fetched, status.Queued, status.Duration.Round(100*time.Millisecond), result)
+	}
+	tw.Flush()
+}
+
 func resolveWebhook(cliValue, configValue string) string {
 	candidates := []string{
 		strings.TrimSpace(cliValue),
@@ -189,7 +365,10 @@ func parseDateOrNow(dateStr string) time.Time {
 func printUsage() {
 	fmt.Fprintln(os.Stderr, "paper-radar: track and score arXiv papers")
 	fmt.Fprintln(os.Stderr, "usage:")
-	fmt.Fprintln(os.Stderr, "  paper-radar fetch  -config config.yaml [-with-kimi]")
+	fmt.Fprintln(os.Stderr, "  paper-radar fetch  -config config.yaml [-with-kimi] [-kimi-top N] [-max-failed 0.5]")
 	fmt.Fprintln(os.Stderr, "  paper-radar digest -out outputs [-top 20] [-pdf]")
-	fmt.Fprintln(os.Stderr, "  paper-radar run    -config config.yaml -out outputs [-top 20] [-with-kimi] [-feishu-webhook URL] [-notify-max-chars 2800] [-pdf]")
+	fmt.Fprintln(os.Stderr, "  paper-radar run    -config config.yaml -out outputs [-top 20] [-with-kimi] [-feishu-webhook URL] [-notify-max-chars 2800] [-max-failed 0.5] [-pdf]")
+	fmt.Fprintln(os.Stderr, "  paper-radar feedback [-state path] <id> up|down")
+	fmt.Fprintln(os.Stderr, "  paper-radar tune   -config config.yaml [-min-labels 3] [-max-candidates 5] [-write]")
+	fmt.Fprintln(os.Stderr, "  paper-radar cache  stats|prune [-config config.yaml] [-state path]")
 }
@@ -1,6 +1,13 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/app"
+)
 
 func TestResolveWebhookPrecedence(t *testing.T) {
 	t.Setenv("PAPER_RADAR_FEISHU_WEBHOOK", "env")
@@ -15,3 +22,19 @@ func TestResolveWebhookPrecedence(t *testing.T) {
 		t.Fatalf("expected env fallback, got %q", got)
 	}
 }
+
+func TestPrintTopicStatuses(t *testing.T) {
+	var b strings.Builder
+	printTopicStatuses(&b, []app.TopicStatus{
+		{Name: "Video", Source: "arxiv", Fetched: 40, Queued: 3, Duration: 2340 * time.Millisecond},
+		{Name: "Agents", Source: "paperscool", Err: errors.New("unexpected status 503")},
+	})
+
+	want := `TOPIC   SOURCE      FETCHED  QUEUED  TIME  STATUS
+Video   arxiv       40       3       2.3s  ok
+Agents  paperscool  0        0       0s    error: unexpected status 503
+`
+	if b.String() != want {
+		t.Fatalf("unexpected table:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kyc001/paper-radar/internal/cache"
+	"github.com/kyc001/paper-radar/internal/config"
+)
+
+type CacheOptions struct {
+	ConfigPath string
+	StatePath  string
+}
+
+// openCache returns the enrichment cache that lives next to the state file.
+func openCache(cfg config.Config, statePath string) *cache.Cache {
+	return cache.Open(filepath.Join(filepath.Dir(statePath), cache.DirName), cfg.CacheOptions())
+}
+
+// OpenCache opens the enrichment cache with the limits from the config.
+func OpenCache(opts CacheOptions) (*cache.Cache, error) {
+	cfg, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return openCache(cfg, defaultStatePath(opts.StatePath)), nil
+}
@@ -5,10 +5,14 @@ import (
 	"time"
 
 	"github.com/kyc001/paper-radar/internal/digest"
+	"github.com/kyc001/paper-radar/internal/model"
 	"github.com/kyc001/paper-radar/internal/scoring"
 	"github.com/kyc001/paper-radar/internal/state"
 )
 
+// maxDigested bounds the digest history kept in state for feedback.
+const maxDigested = 1000
+
 type DigestOptions struct {
 	StatePath string
 	OutputDir string
@@ -46,6 +50,7 @@ func RunDigest(opts DigestOptions) (string, int, error) {
 	}
 
 	count := len(target)
+	st.Digested = appendDigested(st.Digested, target)
 	if count >= len(st.Pending) {
 		st.Pending = nil
 	} else {
@@ -62,6 +67,21 @@ func RunDigest(opts DigestOptions) (string, int, error) {
 	return outputPath, count, nil
 }
 
+// appendDigested adds papers to the digest history, newest last, dropping
+// the oldest entries past maxDigested. AI summaries and score breakdowns are
+// not kept; feedback only needs what the paper was scored on.
+func appendDigested(history []model.ScoredPaper, papers []model.ScoredPaper) []model.ScoredPaper {
+	for _, paper := range papers {
+		paper.Paper.AISummary = ""
+		paper.Breakdown = nil
+		history = append(history, paper)
+	}
+	if len(history) > maxDigested {
+		history = history[len(history)-maxDigested:]
+	}
+	return history
+}
+
 func defaultOutputDir(path string) string {
 	if path != "" {
 		return path
@@ -20,11 +20,11 @@ func TestRunDigestTopNLeavesRemainingPending(t *testing.T) {
 
 	store := state.New(statePath)
 	seed := state.FileState{
-		SeenIDs: map[string]bool{"a": true, "b": true, "c": true},
+		Seen: map[string]model.SeenPaper{"a": {}, "b": {}, "c": {}},
 		Pending: []model.ScoredPaper{
-			{Paper: model.Paper{ID: "a", Title: "A", Summary: "s"}, Score: 10, Topics: []string{"t1"}},
-			{Paper: model.Paper{ID: "b", Title: "B", Summary: "s"}, Score: 8, Topics: []string{"t1"}},
-			{Paper: model.Paper{ID: "c", Title: "C", Summary: "s"}, Score: 6, Topics: []string{"t2"}},
+			{Paper: model.Paper{ID: "a", Title: "A", Abstract: "s"}, Score: 10, Topics: []string{"t1"}},
+			{Paper: model.Paper{ID: "b", Title: "B", Abstract: "s"}, Score: 8, Topics: []string{"t1"}},
+			{Paper: model.Paper{ID: "c", Title: "C", Abstract: "s"}, Score: 6, Topics: []string{"t2"}},
 		},
 	}
 	if err := store.Save(seed); err != nil {
@@ -62,6 +62,9 @@ func TestRunDigestTopNLeavesRemainingPending(t *testing.T) {
 	if after.Pending[0].Paper.ID != "c" {
 		t.Fatalf("expected remaining pending paper c, got %s", after.Pending[0].Paper.ID)
 	}
+	if len(after.Digested) != 2 || after.Digested[0].Paper.ID != "a" || after.Digested[1].Paper.ID != "b" {
+		t.Fatalf("expected a and b in digest history, got %#v", after.Digested)
+	}
 }
 
 func mustReadFile(t *testing.T, path string) string {
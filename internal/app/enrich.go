@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/kyc001/paper-radar/internal/citations"
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/scoring"
+)
+
+// kimiConcurrency bounds concurrent Kimi lookups; the papers.cool per-host
+// limit still applies on top.
+const kimiConcurrency = 4
+
+// kimiSummarizer fetches a Kimi summary by arXiv ID.
+type kimiSummarizer interface {
+	FetchKimiSummary(ctx context.Context, paperID string) (string, error)
+}
+
+// kimiCandidates returns the indexes of pending papers to enrich with a
+// Kimi summary: papers with an arXiv ID and no summary yet, queued by a
+// topic of one of kimiSources that asks for one (every such topic when
+// withKimi is set). With top > 0 only the top papers by score, the ones
+// the next digest will show, are considered, whenever they were queued;
+// otherwise only papers queued in this run.
+func kimiCandidates(cfg config.Config, kimiSources map[string]bool, pending []model.ScoredPaper, queued map[string]bool, withKimi bool, top int) []int {
+	wantsKimi := make(map[string]bool)
+	for _, topic := range cfg.Topics {
+		if kimiSources[topic.Source] && (withKimi || topic.KimiSummary) {
+			wantsKimi[topic.Name] = true
+		}
+	}
+	if len(wantsKimi) == 0 {
+		return nil
+	}
+
+	eligible := queued
+	if top > 0 {
+		ranked := slices.Clone(pending)
+		scoring.SortByScore(ranked)
+		eligible = make(map[string]bool, top)
+		for _, paper := range ranked[:min(top, len(ranked))] {
+			eligible[paper.Paper.ID] = true
+		}
+	}
+
+	var indexes []int
+	for i, paper := range pending {
+		if !eligible[paper.Paper.ID] || paper.Paper.AISummary != "" {
+			continue
+		}
+		if _, ok := model.ParseArxivID(paper.Paper.ID); !ok {
+			continue
+		}
+		if slices.ContainsFunc(paper.Topics, func(topic string) bool { return wantsKimi[topic] }) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// enrichKimi fills in the AI summary of papers[i] for each index, a few
+// lookups at a time, and returns how many it got. A failed lookup leaves the
+// paper as it was; the next run tries again.
+func enrichKimi(ctx context.Context, client kimiSummarizer, papers []model.ScoredPaper, indexes []int) int {
+	var enriched atomic.Int32
+	forEachConcurrently(len(indexes), kimiConcurrency, func(k int) {
+		paper := &papers[indexes[k]]
+		summary, err := client.FetchKimiSummary(ctx, paper.Paper.ID)
+		if err != nil {
+			return
+		}
+		if summary = strings.TrimSpace(summary); summary != "" {
+			paper.Paper.AISummary = summary
+			enriched.Add(1)
+		}
+	})
+	return int(enriched.Load())
+}
+
+// citationLooker looks papers up for citation data by canonical ID.
+type citationLooker interface {
+	Lookup(ctx context.Context, ids []string) (map[string]model.Citations, error)
+}
+
+// enrichCitations attaches citation data to the pending papers that have
+// none yet and can be looked up, and adds the points of their topics'
+// citation rules. It returns how many papers got data and the lookup's
+// error, if any; what the lookup found before failing is still applied.
+// Papers the lookup missed, or that a failed lookup left out, are tried
+// again next run.
+func enrichCitations(ctx context.Context, cfg config.Config, client citationLooker, papers []model.ScoredPaper) (int, error) {
+	var ids []string
+	for _, paper := range papers {
+		if paper.Paper.Citations == nil && citations.Supported(paper.Paper.ID) {
+			ids = append(ids, paper.Paper.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	found, err := client.Lookup(ctx, ids)
+
+	topics := make(map[string]config.Topic, len(cfg.Topics))
+	for _, topic := range cfg.Topics {
+		topics[topic.Name] = topic
+	}
+	enriched := 0
+	for i := range papers {
+		paper := &papers[i]
+		data, ok := found[paper.Paper.ID]
+		if !ok || paper.Paper.Citations != nil {
+			continue
+		}
+		paper.Paper.Citations = &data
+		for _, name := range paper.Topics {
+			for _, hit := range scoring.CitationHits(topics[name], data) {
+				paper.Breakdown = append(paper.Breakdown, hit)
+				paper.Score += hit.Points
+			}
+		}
+		enriched++
+	}
+	return enriched, err
+}
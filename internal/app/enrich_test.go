@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+func TestKimiCandidates(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{Topics: []config.Topic{
+		{Name: "Kimi", Source: "paperscool", KimiSummary: true},
+		{Name: "Plain", Source: "paperscool"},
+		{Name: "Arxiv", Source: "arxiv", KimiSummary: true},
+	}}
+	scored := func(id string, score float64, topic string) model.ScoredPaper {
+		return model.ScoredPaper{Paper: model.Paper{ID: id}, Score: score, Topics: []string{topic}}
+	}
+	pending := []model.ScoredPaper{
+		scored("2602.00001", 9, "Kimi"),  // queued earlier
+		scored("2602.00002", 8, "Kimi"),  // new
+		scored("2602.00003", 7, "Plain"), // new, topic without Kimi
+		scored("2602.00004", 6, "Arxiv"), // new, not a papers.cool topic
+		scored("2602.00005", 5, "Kimi"),  // new
+		scored("paper-x", 4, "Kimi"),     // new, not an arXiv paper
+	}
+	pending[1].Paper.AISummary = "already summarized"
+	kimiSources := map[string]bool{"paperscool": true}
+	queued := map[string]bool{"2602.00002": true, "2602.00003": true, "2602.00004": true, "2602.00005": true, "paper-x": true}
+
+	if got := kimiCandidates(cfg, kimiSources, pending, queued, false, 0); len(got) != 1 || got[0] != 4 {
+		t.Fatalf("expected only the new Kimi-topic paper without a summary, got %v", got)
+	}
+	if got := kimiCandidates(cfg, kimiSources, pending, queued, true, 0); len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Fatalf("with-kimi should cover every papers.cool topic, got %v", got)
+	}
+	// The top 3 are 00001 (queued earlier but still pending), 00002
+	// (summarized) and 00003 (no Kimi for its topic).
+	if got := kimiCandidates(cfg, kimiSources, pending, queued, false, 3); len(got) != 1 || got[0] != 0 {
+		t.Fatalf("expected only the top pending paper, got %v", got)
+	}
+}
+
+type fakeKimi struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	release  chan struct{}
+}
+
+func (f *fakeKimi) FetchKimiSummary(ctx context.Context, id string) (string, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+	<-f.release
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	if id == "2602.00003" {
+		return "", errors.New("kimi status: 404")
+	}
+	return "  summary of " + id + "\n", nil
+}
+
+func TestEnrichKimiBoundsConcurrency(t *testing.T) {
+	t.Parallel()
+
+	papers := make([]model.ScoredPaper, 10)
+	indexes := make([]int, len(papers))
+	for i := range papers {
+		papers[i].Paper.ID = fmt.Sprintf("2602.%05d", i)
+		indexes[i] = i
+	}
+	kimi := &fakeKimi{release: make(chan struct{})}
+	go func() {
+		for range papers {
+			kimi.release <- struct{}{}
+		}
+	}()
+
+	if got := enrichKimi(context.Background(), kimi, papers, indexes); got != 9 {
+		t.Fatalf("expected 9 summaries, got %d", got)
+	}
+	if kimi.peak > kimiConcurrency {
+		t.Fatalf("expected at most %d lookups at once, got %d", kimiConcurrency, kimi.peak)
+	}
+	if papers[0].Paper.AISummary != "summary of 2602.00000" || papers[3].Paper.AISummary != "" {
+		t.Fatalf("unexpected summaries: %q / %q", papers[0].Paper.AISummary, papers[3].Paper.AISummary)
+	}
+}
+
+type fakeCitations struct {
+	asked []string
+}
+
+func (f *fakeCitations) Lookup(ctx context.Context, ids []string) (map[string]model.Citations, error) {
+	f.asked = append(f.asked, ids...)
+	return map[string]model.Citations{
+		"2602.00001":      {Count: 40},
+		"doi:10.1000/xyz": {Count: 2, Venue: "Nature"},
+	}, errors.New("batch 2 failed")
+}
+
+func TestEnrichCitationsAppliesTopicRules(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{Topics: []config.Topic{
+		{Name: "A", CitationRules: []config.CitationRule{{Field: config.CitationCount, Min: 10, Bonus: 4}}},
+		{Name: "B", CitationRules: []config.CitationRule{{Field: config.CitationVenue, Match: "nature", Bonus: 1}}},
+	}}
+	papers := []model.ScoredPaper{
+		{Paper: model.Paper{ID: "2602.00001"}, Score: 5, Topics: []string{"A", "B"}},
+		{Paper: model.Paper{ID: "doi:10.1000/xyz"}, Score: 5, Topics: []string{"B"}},
+		{Paper: model.Paper{ID: "2602.00002", Citations: &model.Citations{Count: 1}}, Score: 5, Topics: []string{"A"}},
+		{Paper: model.Paper{ID: "https://lab.example/post"}, Score: 5, Topics: []string{"A"}},
+		{Paper: model.Paper{ID: "2602.00003"}, Score: 5, Topics: []string{"A"}},
+	}
+	client := &fakeCitations{}
+
+	// What the lookup found before failing is applied and the error is
+	// returned; papers it missed are simply left without data.
+	got, err := enrichCitations(context.Background(), cfg, client, papers)
+	if err == nil || !strings.Contains(err.Error(), "batch 2 failed") {
+		t.Fatalf("expected the lookup error, got %v", err)
+	}
+	if got != 2 {
+		t.Fatalf("expected 2 papers with citation data, got %d", got)
+	}
+	if len(client.asked) != 3 {
+		t.Fatalf("expected only supported papers without data to be looked up, got %v", client.asked)
+	}
+	if papers[0].Score != 9 || len(papers[0].Breakdown) != 1 || papers[0].Paper.Citations.Count != 40 {
+		t.Fatalf("expected topic A's rule to add 4 points, got %#v", papers[0])
+	}
+	if papers[1].Score != 6 || papers[1].Paper.Citations.Venue != "Nature" {
+		t.Fatalf("expected topic B's venue rule to add 1 point, got %#v", papers[1])
+	}
+	if papers[4].Paper.Citations != nil || papers[4].Score != 5 {
+		t.Fatalf("a paper the lookup missed should be unchanged, got %#v", papers[4])
+	}
+}
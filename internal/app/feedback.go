@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/feedback"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/state"
+)
+
+type FeedbackOptions struct {
+	StatePath string
+	ID        string
+	Label     string
+}
+
+// RunFeedback records an up/down judgment of a digested or pending paper.
+func RunFeedback(opts FeedbackOptions) (model.Feedback, error) {
+	store := state.New(defaultStatePath(opts.StatePath))
+	st, err := store.Load()
+	if err != nil {
+		return model.Feedback{}, fmt.Errorf("load state: %w", err)
+	}
+
+	record, err := feedback.Record(&st, opts.ID, opts.Label, time.Now())
+	if err != nil {
+		return model.Feedback{}, err
+	}
+
+	if err := store.Save(st); err != nil {
+		return model.Feedback{}, fmt.Errorf("save state: %w", err)
+	}
+	return record, nil
+}
+
+type TuneOptions struct {
+	ConfigPath    string
+	StatePath     string
+	MinLabels     int
+	MaxCandidates int
+	Write         bool
+}
+
+type TuneResult struct {
+	Labels    int
+	Proposals []feedback.Proposal
+	// Diff is a unified diff from the current config to the proposed one;
+	// empty when nothing is proposed.
+	Diff    string
+	Written bool
+}
+
+// RunTune proposes keyword weight changes and new keywords from the recorded
+// feedback as a diff against the config file, and applies it when Write is
+// set.
+func RunTune(opts TuneOptions) (TuneResult, error) {
+	cfg, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		return TuneResult{}, fmt.Errorf("load config: %w", err)
+	}
+
+	st, err := state.New(defaultStatePath(opts.StatePath)).Load()
+	if err != nil {
+		return TuneResult{}, fmt.Errorf("load state: %w", err)
+	}
+
+	result := TuneResult{Labels: len(st.Feedback)}
+	result.Proposals = feedback.Tune(cfg.Topics, st.Feedback, st.Digested, feedback.Options{
+		MinLabels:     opts.MinLabels,
+		MaxCandidates: opts.MaxCandidates,
+	})
+	if len(result.Proposals) == 0 {
+		return result, nil
+	}
+
+	var edits []config.KeywordEdit
+	for _, proposal := range result.Proposals {
+		for _, change := range proposal.Changes {
+			edit := config.KeywordEdit{Topic: proposal.Topic, Word: change.Word, Weight: change.New}
+			if change.Old == 0 {
+				edit.Comment = fmt.Sprintf("tune: in %d/%d up, %d/%d down", change.Up, proposal.Up, change.Down, proposal.Down)
+			}
+			edits = append(edits, edit)
+		}
+	}
+
+	before, err := os.ReadFile(opts.ConfigPath)
+	if err != nil {
+		return TuneResult{}, fmt.Errorf("read config: %w", err)
+	}
+	after, err := config.EditKeywords(before, edits)
+	if err != nil {
+		return TuneResult{}, fmt.Errorf("edit config: %w", err)
+	}
+	// The edited file must still load exactly like a hand-written one.
+	proposed, err := config.Parse(after)
+	if err == nil {
+		err = proposed.Validate()
+	}
+	if err == nil {
+		err = proposed.CheckSources(Registry{})
+	}
+	if err != nil {
+		return TuneResult{}, fmt.Errorf("proposed config is invalid: %w", err)
+	}
+
+	result.Diff = unifiedDiff(opts.ConfigPath, string(before), string(after))
+	if !opts.Write || result.Diff == "" {
+		return result, nil
+	}
+
+	info, err := os.Stat(opts.ConfigPath)
+	if err != nil {
+		return TuneResult{}, err
+	}
+	if err := os.WriteFile(opts.ConfigPath, after, info.Mode().Perm()); err != nil {
+		return TuneResult{}, fmt.Errorf("write config: %w", err)
+	}
+	result.Written = true
+	return result, nil
+}
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// unifiedDiff renders a line diff of before and after in unified format,
+// or "" when they are equal. Configs are small, so a plain LCS table does.
+func unifiedDiff(name, before, after string) string {
+	if before == after {
+		return ""
+	}
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		kind byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s (proposed)\n", name, name)
+
+	aLine, bLine := 1, 1
+	for start := 0; start < len(lines); {
+		if lines[start].kind == ' ' {
+			aLine++
+			bLine++
+			start++
+			continue
+		}
+
+		// Grow the hunk while the next change is close enough to share context.
+		from := max(0, start-diffContext)
+		to := start
+		for k := start; k < len(lines) && k <= to+2*diffContext; k++ {
+			if lines[k].kind != ' ' {
+				to = k
+			}
+		}
+		to = min(len(lines)-1, to+diffContext)
+
+		aStart, bStart := aLine-(start-from), bLine-(start-from)
+		aCount, bCount := 0, 0
+		var body strings.Builder
+		for k := from; k <= to; k++ {
+			body.WriteByte(lines[k].kind)
+			body.WriteString(lines[k].text)
+			body.WriteByte('\n')
+			if lines[k].kind != '+' {
+				aCount++
+			}
+			if lines[k].kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n%s", aStart, aCount, bStart, bCount, body.String())
+
+		for k := start; k <= to; k++ {
+			if lines[k].kind != '+' {
+				aLine++
+			}
+			if lines[k].kind != '-' {
+				bLine++
+			}
+		}
+		start = to + 1
+	}
+	return out.String()
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/state"
+)
+
+func TestRunFeedbackAndTuneWriteConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	configPath := filepath.Join(dir, "config.yaml")
+	configSource := `# daily radar
+topics:
+  - name: "Video"
+    source: "arxiv"
+    query: "cat:cs.CV"
+    match: token
+    keywords:
+      - "video"
+      - "segmentation"
+`
+	if err := os.WriteFile(configPath, []byte(configSource), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	digested := func(id, title, abstract string) model.ScoredPaper {
+		return model.ScoredPaper{Paper: model.Paper{ID: id, Version: 1, Title: title, Abstract: abstract}, Topics: []string{"Video"}}
+	}
+	if err := state.New(statePath).Save(state.FileState{Digested: []model.ScoredPaper{
+		digested("2603.00001", "Streaming video generation", "Gaussian splatting for long video"),
+		digested("2603.00002", "Video world models", "Gaussian splatting with memory"),
+		digested("2603.00003", "Medical video segmentation", "Lesion segmentation"),
+	}}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	for id, label := range map[string]string{"2603.00001": "up", "2603.00002": "up", "2603.00003": "down"} {
+		if _, err := RunFeedback(FeedbackOptions{StatePath: statePath, ID: id, Label: label}); err != nil {
+			t.Fatalf("feedback %s: %v", id, err)
+		}
+	}
+
+	result, err := RunTune(TuneOptions{ConfigPath: configPath, StatePath: statePath})
+	if err != nil {
+		t.Fatalf("tune: %v", err)
+	}
+	if result.Labels != 3 || result.Written {
+		t.Fatalf("unexpected tune result: %#v", result)
+	}
+	for _, want := range []string{
+		"--- " + configPath,
+		`+      - {word: "gaussian splatting", weight: 1}  # tune: in 2/2 up, 0/1 down`,
+	} {
+		if !strings.Contains(result.Diff, want) {
+			t.Fatalf("diff missing %q:\n%s", want, result.Diff)
+		}
+	}
+	if strings.Contains(result.Diff, `"segmentation", weight`) || strings.Contains(result.Diff, `"splatting"`) {
+		// segmentation is already at weight 1 and cannot drop below it;
+		// splatting is covered by the gaussian splatting phrase.
+		t.Fatalf("unexpected change in diff:\n%s", result.Diff)
+	}
+
+	if got := mustReadFile(t, configPath); got != configSource {
+		t.Fatalf("tune without -write must not touch the config")
+	}
+
+	written, err := RunTune(TuneOptions{ConfigPath: configPath, StatePath: statePath, Write: true})
+	if err != nil {
+		t.Fatalf("tune -write: %v", err)
+	}
+	if !written.Written || !strings.Contains(mustReadFile(t, configPath), `{word: "gaussian splatting", weight: 1}`) {
+		t.Fatalf("expected the proposal to be written:\n%s", mustReadFile(t, configPath))
+	}
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	t.Parallel()
+
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	want := "--- f\n+++ f (proposed)\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -8,4 +8,5 @@\n h\n i\n j\n+k\n \n"
+	if got := unifiedDiff("f", before, after); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if got := unifiedDiff("f", before, before); got != "" {
+		t.Fatalf("expected no diff for equal input, got %q", got)
+	}
+}
@@ -2,35 +2,100 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"sort"
+	"sync"
+	"time"
 
 	"github.com/kyc001/paper-radar/internal/arxiv"
+	"github.com/kyc001/paper-radar/internal/citations"
 	"github.com/kyc001/paper-radar/internal/config"
 	"github.com/kyc001/paper-radar/internal/model"
 	"github.com/kyc001/paper-radar/internal/paperscool"
 	"github.com/kyc001/paper-radar/internal/scoring"
+	"github.com/kyc001/paper-radar/internal/seeds"
+	"github.com/kyc001/paper-radar/internal/source"
 	"github.com/kyc001/paper-radar/internal/state"
 )
 
 const DefaultStatePath = ".paper-radar/state.json"
 
+// maxVetoRecords bounds the veto audit trail kept in state.
+const maxVetoRecords = 500
+
+// maxCorpusRuns bounds the per-run corpus stats BM25/TF-IDF topics rank
+// against, in addition to the current run.
+const maxCorpusRuns = 30
+
 type FetchOptions struct {
 	ConfigPath string
 	StatePath  string
 	MaxResults int
-	MinScore   int
+	MinScore   float64
 	WithKimi   bool
+	// KimiTop limits Kimi enrichment to the top N pending papers by score,
+	// the ones a digest of that size will show. 0 enriches every paper
+	// queued in this run.
+	KimiTop int
+	// Now is the run time recorded in state; zero means the current time.
+	Now time.Time
+
+	// seedLookup replaces the arXiv client seed papers are looked up with.
+	seedLookup seeds.Lookup
 }
 
 type FetchResult struct {
 	Fetched int
 	Queued  int
+	Vetoed  int
 	Topics  int
+	// Enriched counts papers that gained a Kimi summary.
+	Enriched int
+	// Cited counts papers that gained citation data. CitationErr is a
+	// warning, not a failure: the citation lookup failed (a bad api_key,
+	// rate limiting), so some papers may be left without data until the
+	// next run.
+	Cited       int
+	CitationErr error
+	// Statuses reports each topic in config order.
+	Statuses []TopicStatus
+}
+
+// TopicStatus is how one topic fared in a fetch. Err is set when its source
+// failed or its seed papers could not be loaded (the topic is then scored
+// without seed similarity); the run carries on with the other topics.
+// Queued counts the topic's papers queued in this run, including ones other
+// topics also matched.
+type TopicStatus struct {
+	Name     string
+	Source   string
+	Fetched  int
+	Queued   int
+	Err      error
+	Duration time.Duration
+}
+
+// Failed returns how many topics could not be fetched.
+func (r FetchResult) Failed() int {
+	failed := 0
+	for _, status := range r.Statuses {
+		if status.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// FailedFraction returns the share of topics that could not be fetched.
+func (r FetchResult) FailedFraction() float64 {
+	if len(r.Statuses) == 0 {
+		return 0
+	}
+	return float64(r.Failed()) / float64(len(r.Statuses))
 }
 
 func RunFetch(ctx context.Context, opts FetchOptions) (FetchResult, error) {
-	cfg, err := config.Load(opts.ConfigPath)
+	cfg, err := loadConfig(opts.ConfigPath)
 	if err != nil {
 		return FetchResult{}, fmt.Errorf("load config: %w", err)
 	}
@@ -41,77 +106,285 @@ func RunFetch(ctx context.Context, opts FetchOptions) (FetchResult, error) {
 		return FetchResult{}, fmt.Errorf("load state: %w", err)
 	}
 
-	arxivClient := arxiv.NewClient()
-	papersCoolClient := paperscool.NewClient()
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	enrichments := openCache(cfg, store.Path())
+	progress := newCheckpoints(st.Checkpoints)
+	env := source.Env{HTTP: cfg.HTTPPolicy, Cache: enrichments, Checkpoints: progress}
+	sources, queries, err := topicSources(cfg, env, opts.MaxResults, now)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	// Seeds are looked up by arXiv ID and Kimi summaries come from
+	// papers.cool whichever source a topic uses.
+	var seedLookup seeds.Lookup = arxiv.NewClient(cfg.HTTPPolicy("arxiv"))
+	if opts.seedLookup != nil {
+		seedLookup = opts.seedLookup
+	}
+	kimiClient := paperscool.NewClient(cfg.HTTPPolicy("paperscool"))
+	kimiClient.UseCache(enrichments)
+
 	newByID := make(map[string]model.ScoredPaper)
-	originalSeen := cloneSeen(st.SeenIDs)
+	originalSeen := cloneSeen(st.Seen)
 	fetchedCount := 0
+	var vetoes []model.VetoRecord
 
-	for _, topic := range cfg.Topics {
-		maxResults := cfg.EffectiveMaxResults(topic, opts.MaxResults)
-		minScore := cfg.EffectiveMinScore(topic, opts.MinScore)
-		query := cfg.TopicQuery(topic)
-
-		var papers []model.Paper
-		switch topic.Source {
-		case "paperscool":
-			papers, err = papersCoolClient.Fetch(ctx, query, maxResults, opts.WithKimi || topic.KimiSummary)
-		default:
-			papers, err = arxivClient.Fetch(ctx, query, maxResults)
-		}
-		if err != nil {
-			return FetchResult{}, fmt.Errorf("fetch topic %q: %w", topic.Name, err)
+	// Fetch every topic before scoring so BM25/TF-IDF topics can rank
+	// against all papers seen in this run. Topics are fetched concurrently
+	// but their results are kept in config order, so scoring and merging
+	// below do not depend on which request finished first. A failing topic
+	// is reported and its papers stay unseen, so the next run picks them
+	// up; only what a source returns alongside its error (the pages a
+	// harvest got through before failing) is kept.
+	fetched := make([][]model.Paper, len(cfg.Topics))
+	statuses := make([]TopicStatus, len(cfg.Topics))
+	forEachConcurrently(len(cfg.Topics), cfg.EffectiveConcurrency(), func(i int) {
+		topic := cfg.Topics[i]
+		started := time.Now()
+		papers, err := sources[i].Fetch(ctx, queries[i])
+		statuses[i] = TopicStatus{Name: topic.Name, Source: topic.Source, Fetched: len(papers), Err: err, Duration: time.Since(started)}
+		fetched[i] = papers
+	})
+	for _, papers := range fetched {
+		fetchedCount += len(papers)
+	}
+
+	unique := uniquePapers(fetched)
+	runStats := scoring.CollectStats(unique, cfg.Topics)
+	runStats.At = now
+	corpus := scoring.NewCorpus(append(st.Corpus, runStats)...)
+	seedErrs := attachSeeds(ctx, cfg, opts.ConfigPath, store.Path(), seedLookup, unique, corpus)
+	for i, topic := range cfg.Topics {
+		if err := seedErrs[topic.Name]; err != nil {
+			statuses[i].Err = errors.Join(statuses[i].Err, fmt.Errorf("load seed papers: %w", err))
 		}
+	}
 
-		fetchedCount += len(papers)
-		for _, paper := range papers {
-			processPaper(originalSeen, st.SeenIDs, newByID, topic, paper, minScore)
+	for i, topic := range cfg.Topics {
+		minScore := cfg.EffectiveMinScore(topic, opts.MinScore)
+		for _, paper := range fetched[i] {
+			if veto, vetoed := processPaper(originalSeen, st.Seen, newByID, topic, paper, minScore, corpus); vetoed {
+				veto.VetoedAt = now
+				vetoes = append(vetoes, veto)
+			}
 		}
 	}
 
 	newPapers := mapToSortedSlice(newByID)
-	st.Pending = append(st.Pending, newPapers...)
+	countQueued(statuses, newPapers)
+	st.Pending = mergePending(st.Pending, newPapers)
 
+	// Kimi summaries are fetched only now, for papers that made it into
+	// the queue, rather than for every feed entry.
+	queued := make(map[string]bool, len(newPapers))
+	for _, paper := range newPapers {
+		queued[paper.Paper.ID] = true
+	}
+	candidates := kimiCandidates(cfg, kimiSources(cfg, sources), st.Pending, queued, opts.WithKimi, opts.KimiTop)
+	enriched := enrichKimi(ctx, kimiClient, st.Pending, candidates)
+	cited := 0
+	var citationErr error
+	if cfg.Citations.Enabled {
+		citationClient := citations.NewClient(cfg.Citations.BaseURL, cfg.Citations.APIKey, cfg.HTTPPolicy("citations"))
+		citationClient.UseCache(enrichments)
+		cited, citationErr = enrichCitations(ctx, cfg, citationClient, st.Pending)
+	}
+
+	st.Vetoed = append(st.Vetoed, vetoes...)
+	if len(st.Vetoed) > maxVetoRecords {
+		st.Vetoed = st.Vetoed[len(st.Vetoed)-maxVetoRecords:]
+	}
+	if runStats.Docs > 0 {
+		st.Corpus = append(st.Corpus, runStats)
+		if len(st.Corpus) > maxCorpusRuns {
+			st.Corpus = st.Corpus[len(st.Corpus)-maxCorpusRuns:]
+		}
+	}
+
+	st.Checkpoints = progress.values
 	if err := store.Save(st); err != nil {
 		return FetchResult{}, fmt.Errorf("save state: %w", err)
 	}
+	if enriched > 0 || cited > 0 {
+		if _, err := enrichments.Prune(); err != nil {
+			return FetchResult{}, fmt.Errorf("prune enrichment cache: %w", err)
+		}
+	}
 
 	return FetchResult{
-		Fetched: fetchedCount,
-		Queued:  len(newPapers),
-		Topics:  len(cfg.Topics),
+		Fetched:     fetchedCount,
+		Queued:      len(newPapers),
+		Vetoed:      len(vetoes),
+		Topics:      len(cfg.Topics),
+		Enriched:    enriched,
+		Cited:       cited,
+		CitationErr: citationErr,
+		Statuses:    statuses,
 	}, nil
 }
 
-func processPaper(originalSeen map[string]bool, seenIDs map[string]bool, byID map[string]model.ScoredPaper, topic config.Topic, paper model.Paper, minScore int) {
-	if originalSeen[paper.ID] {
-		return
+// countQueued fills in each status's Queued from the topics the queued
+// papers matched.
+func countQueued(statuses []TopicStatus, queued []model.ScoredPaper) {
+	index := make(map[string]int, len(statuses))
+	for i, status := range statuses {
+		index[status.Name] = i
+	}
+	for _, paper := range queued {
+		for _, topic := range paper.Topics {
+			if i, ok := index[topic]; ok {
+				statuses[i].Queued++
+			}
+		}
+	}
+}
+
+// processPaper scores paper for topic and merges it into byID when it passes
+// minScore. Papers seen in an earlier run are only scored again when they
+// are a revision and the topic asks to be notified of revisions; they are
+// then queued marked as updated. A paper by a followed author or
+// affiliation passes regardless of score when the topic's follow mode is
+// bypass. When an exclude keyword vetoes a paper that would otherwise have
+// passed, the returned record explains why it was dropped.
+func processPaper(originalSeen map[string]model.SeenPaper, seen map[string]model.SeenPaper, byID map[string]model.ScoredPaper, topic config.Topic, paper model.Paper, minScore float64, corpus *scoring.Corpus) (model.VetoRecord, bool) {
+	// Sources already report canonical IDs; this keeps dedup across
+	// sources intact for one that reports arXiv URLs. Other IDs, such as
+	// the feed source's "feed:" and "doi:" ones, pass through unchanged.
+	id, version := model.CanonicalID(paper.ID)
+	paper.ID = id
+	if paper.Version == 0 {
+		paper.Version = version
+	}
+
+	// Record the paper even if it doesn't pass threshold, so the next run
+	// won't reprocess it.
+	seen[paper.ID] = seen[paper.ID].Track(paper)
+
+	prior, wasSeen := originalSeen[paper.ID]
+	revised := wasSeen && prior.IsRevisedBy(paper)
+	if wasSeen && !(revised && topic.NotifyRevisions) {
+		return model.VetoRecord{}, false
 	}
 
-	score := scoring.ScorePaper(paper, topic.Keywords)
-	if score >= minScore {
+	result := scoring.ScoreTopic(paper, topic, corpus)
+	if result.FilteredOut {
+		return model.VetoRecord{}, false
+	}
+
+	score := result.Score
+	passes := score >= minScore || (result.Follows() && topic.FollowMode == config.FollowBypass)
+	if result.Veto != "" {
+		if !passes {
+			return model.VetoRecord{}, false
+		}
+		return model.VetoRecord{
+			ID:     paper.ID,
+			Title:  paper.Title,
+			Topic:  topic.Name,
+			Reason: result.Veto,
+			Score:  score,
+		}, true
+	}
+
+	if passes {
 		existing, ok := byID[paper.ID]
 		if !ok {
 			byID[paper.ID] = model.ScoredPaper{
-				Paper:  paper,
-				Score:  score,
-				Topics: []string{topic.Name},
+				Paper:                paper,
+				Score:                score,
+				Topics:               []string{topic.Name},
+				Breakdown:            result.Hits,
+				Updated:              revised,
+				PreviousVersion:      prior.Version,
+				FollowedAuthors:      result.Followed,
+				FollowedAffiliations: result.FollowedAffiliations,
 			}
 		} else {
 			existing.Score += score
+			existing.Breakdown = append(existing.Breakdown, result.Hits...)
 			existing.Topics = appendIfMissing(existing.Topics, topic.Name)
+			for _, author := range result.Followed {
+				existing.FollowedAuthors = appendIfMissing(existing.FollowedAuthors, author)
+			}
+			for _, affiliation := range result.FollowedAffiliations {
+				existing.FollowedAffiliations = appendIfMissing(existing.FollowedAffiliations, affiliation)
+			}
+			// The same paper may come from another source with enrichment
+			// the first copy lacks.
+			if existing.Paper.AISummary == "" {
+				existing.Paper.AISummary = paper.AISummary
+			}
+			if existing.Paper.Announcement == "" {
+				existing.Paper.Announcement = paper.Announcement
+			}
+			if paper.Version > existing.Paper.Version {
+				existing.Paper.Version = paper.Version
+			}
 			byID[paper.ID] = existing
 		}
 	}
 
-	// Mark as seen even if it didn't pass threshold, so the next run won't reprocess it.
-	seenIDs[paper.ID] = true
+	return model.VetoRecord{}, false
+}
+
+// mergePending appends papers to pending. A revision of a paper that is
+// still pending replaces the older copy in place instead of queueing twice.
+func mergePending(pending, papers []model.ScoredPaper) []model.ScoredPaper {
+	index := make(map[string]int, len(pending))
+	for i, paper := range pending {
+		index[paper.Paper.ID] = i
+	}
+	for _, paper := range papers {
+		if i, ok := index[paper.Paper.ID]; ok {
+			pending[i] = paper
+			continue
+		}
+		pending = append(pending, paper)
+	}
+	return pending
+}
+
+// forEachConcurrently calls fn for 0..n-1 on at most limit goroutines and
+// returns once every call has.
+func forEachConcurrently(n, limit int, fn func(i int)) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(limit, 1))
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// uniquePapers flattens per-topic results, keeping the first copy of each ID.
+func uniquePapers(perTopic [][]model.Paper) []model.Paper {
+	seen := make(map[string]bool)
+	papers := make([]model.Paper, 0)
+	for _, batch := range perTopic {
+		for _, paper := range batch {
+			if seen[paper.ID] {
+				continue
+			}
+			seen[paper.ID] = true
+			papers = append(papers, paper)
+		}
+	}
+	return papers
 }
 
-func cloneSeen(seen map[string]bool) map[string]bool {
-	cloned := make(map[string]bool, len(seen))
-	for id, ok := range seen {
-		cloned[id] = ok
+func cloneSeen(seen map[string]model.SeenPaper) map[string]model.SeenPaper {
+	cloned := make(map[string]model.SeenPaper, len(seen))
+	for id, record := range seen {
+		cloned[id] = record
 	}
 	return cloned
 }
@@ -122,13 +395,7 @@ func mapToSortedSlice(byID map[string]model.ScoredPaper) []model.ScoredPaper {
 		papers = append(papers, paper)
 	}
 
-	sort.Slice(papers, func(i, j int) bool {
-		if papers[i].Score == papers[j].Score {
-			return papers[i].Paper.PublishedAt.After(papers[j].Paper.PublishedAt)
-		}
-		return papers[i].Score > papers[j].Score
-	})
-
+	scoring.SortByScore(papers)
 	return papers
 }
 
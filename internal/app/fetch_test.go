@@ -1,29 +1,38 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/kyc001/paper-radar/internal/config"
 	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/state"
 )
 
 func TestProcessPaperAggregatesAcrossTopics(t *testing.T) {
 	t.Parallel()
 
-	originalSeen := map[string]bool{}
-	seenIDs := map[string]bool{}
+	originalSeen := map[string]model.SeenPaper{}
+	seen := map[string]model.SeenPaper{}
 	byID := map[string]model.ScoredPaper{}
 
 	paper := model.Paper{
 		ID:          "paper-1",
 		Title:       "agent memory",
-		Summary:     "agent memory planning",
+		Abstract:    "agent memory planning",
 		PublishedAt: time.Now(),
 	}
 
-	processPaper(originalSeen, seenIDs, byID, config.Topic{Name: "A", Keywords: []string{"agent"}}, paper, 1)
-	processPaper(originalSeen, seenIDs, byID, config.Topic{Name: "B", Keywords: []string{"memory"}}, paper, 1)
+	processPaper(originalSeen, seen, byID, config.Topic{Name: "A", Keywords: []config.Keyword{{Word: "agent"}}}, paper, 1, nil)
+	processPaper(originalSeen, seen, byID, config.Topic{Name: "B", Keywords: []config.Keyword{{Word: "memory"}}}, paper, 1, nil)
 
 	got, ok := byID[paper.ID]
 	if !ok {
@@ -33,39 +42,444 @@ func TestProcessPaperAggregatesAcrossTopics(t *testing.T) {
 		t.Fatalf("expected 2 topics, got %v", got.Topics)
 	}
 	if got.Score != 4 {
-		t.Fatalf("expected aggregated score 4, got %d", got.Score)
+		t.Fatalf("expected aggregated score 4, got %v", got.Score)
+	}
+	if len(got.Breakdown) != 4 {
+		t.Fatalf("expected breakdown from both topics, got %#v", got.Breakdown)
 	}
 }
 
 func TestProcessPaperRespectsMinScoreButMarksSeen(t *testing.T) {
 	t.Parallel()
 
-	originalSeen := map[string]bool{}
-	seenIDs := map[string]bool{}
+	originalSeen := map[string]model.SeenPaper{}
+	seen := map[string]model.SeenPaper{}
 	byID := map[string]model.ScoredPaper{}
 
-	paper := model.Paper{ID: "paper-2", Title: "weak match", Summary: "just one keyword mention"}
-	processPaper(originalSeen, seenIDs, byID, config.Topic{Name: "A", Keywords: []string{"keyword"}}, paper, 2)
+	paper := model.Paper{ID: "paper-2", Title: "weak match", Abstract: "just one keyword mention"}
+	processPaper(originalSeen, seen, byID, config.Topic{Name: "A", Keywords: []config.Keyword{{Word: "keyword"}}}, paper, 2, nil)
 
 	if _, ok := byID[paper.ID]; ok {
 		t.Fatalf("paper should not pass min-score threshold")
 	}
-	if !seenIDs[paper.ID] {
+	if _, ok := seen[paper.ID]; !ok {
 		t.Fatalf("paper should still be marked as seen")
 	}
 }
 
+func TestProcessPaperFollowBypassIgnoresMinScore(t *testing.T) {
+	t.Parallel()
+
+	originalSeen := map[string]model.SeenPaper{}
+	seen := map[string]model.SeenPaper{}
+	byID := map[string]model.ScoredPaper{}
+	topic := config.Topic{
+		Name:          "A",
+		Keywords:      []config.Keyword{{Word: "keyword"}},
+		FollowAuthors: []config.FollowAuthor{{Name: "Kaiming He"}},
+		FollowMode:    config.FollowBypass,
+	}
+
+	followed := model.Paper{ID: "paper-3", Title: "unrelated", Authors: []string{"Kaiming He"}}
+	other := model.Paper{ID: "paper-4", Title: "unrelated", Authors: []string{"Someone Else"}}
+	processPaper(originalSeen, seen, byID, topic, followed, 5, nil)
+	processPaper(originalSeen, seen, byID, topic, other, 5, nil)
+
+	got, ok := byID[followed.ID]
+	if !ok {
+		t.Fatalf("a paper by a followed author should bypass min_score")
+	}
+	if len(got.FollowedAuthors) != 1 || got.FollowedAuthors[0] != "Kaiming He" {
+		t.Fatalf("expected followed author recorded, got %#v", got.FollowedAuthors)
+	}
+	if _, ok := byID[other.ID]; ok {
+		t.Fatalf("other papers should still respect min_score")
+	}
+}
+
 func TestProcessPaperSkipsOriginalSeen(t *testing.T) {
 	t.Parallel()
 
-	originalSeen := map[string]bool{"paper-3": true}
-	seenIDs := map[string]bool{"paper-3": true}
+	originalSeen := map[string]model.SeenPaper{"paper-3": {}}
+	seen := map[string]model.SeenPaper{"paper-3": {}}
 	byID := map[string]model.ScoredPaper{}
 
-	paper := model.Paper{ID: "paper-3", Title: "agent", Summary: "agent"}
-	processPaper(originalSeen, seenIDs, byID, config.Topic{Name: "A", Keywords: []string{"agent"}}, paper, 1)
+	paper := model.Paper{ID: "paper-3", Title: "agent", Abstract: "agent"}
+	processPaper(originalSeen, seen, byID, config.Topic{Name: "A", Keywords: []config.Keyword{{Word: "agent"}}}, paper, 1, nil)
 
 	if len(byID) != 0 {
 		t.Fatalf("already-seen paper should be skipped")
 	}
 }
+
+func TestProcessPaperDedupsSourcesOnCanonicalID(t *testing.T) {
+	t.Parallel()
+
+	originalSeen := map[string]model.SeenPaper{}
+	seen := map[string]model.SeenPaper{}
+	byID := map[string]model.ScoredPaper{}
+
+	fromArxiv := model.Paper{ID: "http://arxiv.org/abs/2602.23153v1", Title: "agent", Abstract: "agent"}
+	fromPapersCool := model.Paper{ID: "https://papers.cool/arxiv/2602.23153", Title: "agent", Abstract: "agent memory", AISummary: "Q1: ..."}
+	processPaper(originalSeen, seen, byID, config.Topic{Name: "A", Keywords: []config.Keyword{{Word: "agent"}}}, fromArxiv, 1, nil)
+	processPaper(originalSeen, seen, byID, config.Topic{Name: "B", Keywords: []config.Keyword{{Word: "memory"}}}, fromPapersCool, 1, nil)
+
+	if len(byID) != 1 || len(seen) != 1 || seen["2602.23153"].Version != 1 {
+		t.Fatalf("expected one paper keyed on 2602.23153, got byID=%v seen=%v", byID, seen)
+	}
+	got := byID["2602.23153"]
+	if got.Paper.Version != 1 || len(got.Topics) != 2 || got.Paper.AISummary == "" {
+		t.Fatalf("expected merged paper with version 1, both topics and the AI summary, got %#v", got)
+	}
+
+	processPaper(map[string]model.SeenPaper{"2602.23153": {Version: 1}}, seen, byID, config.Topic{Name: "A", Keywords: []config.Keyword{{Word: "agent"}}}, model.Paper{ID: "2602.23153v2", Title: "agent", Abstract: "agent"}, 1, nil)
+	if got := byID["2602.23153"]; got.Score != 3 {
+		t.Fatalf("a new version of a seen paper should be skipped without notify_revisions, score changed to %v", got.Score)
+	}
+}
+
+func TestProcessPaperRequeuesRevisionsWhenTopicOptsIn(t *testing.T) {
+	t.Parallel()
+
+	originalSeen := map[string]model.SeenPaper{"2602.23153": {Version: 1}}
+	seen := cloneSeen(originalSeen)
+	byID := map[string]model.ScoredPaper{}
+	topic := config.Topic{Name: "A", Keywords: []config.Keyword{{Word: "agent"}}, NotifyRevisions: true}
+
+	processPaper(originalSeen, seen, byID, topic, model.Paper{ID: "2602.23153", Version: 1, Title: "agent", Abstract: "agent"}, 1, nil)
+	if len(byID) != 0 {
+		t.Fatalf("a re-fetch of the same version should be skipped, got %#v", byID)
+	}
+
+	processPaper(originalSeen, seen, byID, topic, model.Paper{ID: "2602.23153", Version: 2, Title: "agent", Abstract: "agent v2"}, 1, nil)
+	got, ok := byID["2602.23153"]
+	if !ok || !got.Updated || got.PreviousVersion != 1 || got.Paper.Version != 2 {
+		t.Fatalf("expected revision to be queued as updated from v1, got %#v", got)
+	}
+	if seen["2602.23153"].Version != 2 {
+		t.Fatalf("expected seen version to advance to 2, got %#v", seen["2602.23153"])
+	}
+
+	pending := []model.ScoredPaper{{Paper: model.Paper{ID: "2602.23153", Version: 1}}, {Paper: model.Paper{ID: "other"}}}
+	pending = mergePending(pending, []model.ScoredPaper{got})
+	if len(pending) != 2 || pending[0].Paper.Version != 2 {
+		t.Fatalf("a pending paper's revision should replace it in place, got %#v", pending)
+	}
+}
+
+func TestProcessPaperExcludeVetoKeepsPaperOutOfPending(t *testing.T) {
+	t.Parallel()
+
+	originalSeen := map[string]model.SeenPaper{}
+	seen := map[string]model.SeenPaper{}
+	byID := map[string]model.ScoredPaper{}
+
+	topic := config.Topic{
+		Name:            "3D",
+		Keywords:        []config.Keyword{{Word: "3d"}},
+		ExcludeKeywords: []config.Keyword{{Word: "remote sensing"}},
+		ExcludeMode:     config.ExcludeVeto,
+	}
+	paper := model.Paper{ID: "paper-4", Title: "3D change detection", Abstract: "remote sensing imagery"}
+
+	veto, vetoed := processPaper(originalSeen, seen, byID, topic, paper, 1, nil)
+	if !vetoed {
+		t.Fatalf("paper should be vetoed")
+	}
+	if veto.ID != paper.ID || veto.Topic != topic.Name || veto.Reason == "" {
+		t.Fatalf("unexpected veto record: %#v", veto)
+	}
+	if _, ok := byID[paper.ID]; ok {
+		t.Fatalf("vetoed paper should not be queued")
+	}
+	if _, ok := seen[paper.ID]; !ok {
+		t.Fatalf("vetoed paper should still be marked as seen")
+	}
+}
+
+func TestRunFetchKeepsGoingWhenATopicFails(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken/feed" {
+			http.Error(w, "gone", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><id>https://papers.cool/arxiv/2602.22094</id><title>Agent memory</title><summary>agent</summary></entry>
+</feed>`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	statePath := filepath.Join(dir, "state.json")
+	content := `topics:
+  - name: Broken
+    source: paperscool
+    query: "` + server.URL + `/broken/feed"
+    keywords: [agent]
+  - name: Agents
+    source: paperscool
+    query: "` + server.URL + `/agents/feed"
+    keywords: [agent]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	result, err := RunFetch(context.Background(), FetchOptions{ConfigPath: configPath, StatePath: statePath})
+	if err != nil {
+		t.Fatalf("RunFetch should tolerate a failing topic: %v", err)
+	}
+	if len(result.Statuses) != 2 || result.Statuses[0].Err == nil || result.Statuses[1].Err != nil {
+		t.Fatalf("expected only the first topic to fail, got %#v", result.Statuses)
+	}
+	if result.Statuses[1].Fetched != 1 || result.Statuses[1].Queued != 1 || result.Failed() != 1 || result.FailedFraction() != 0.5 {
+		t.Fatalf("unexpected statuses %#v", result.Statuses)
+	}
+
+	st, err := state.New(statePath).Load()
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(st.Pending) != 1 || st.Pending[0].Paper.ID != "2602.22094" {
+		t.Fatalf("papers from the working topic should be saved, got %#v", st.Pending)
+	}
+}
+
+func TestRunFetchKeepsFeedIDsOpaque(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss version="2.0"><channel>
+  <item><title>Agent news</title><description>agent</description><link>https://lab.org/news/1234567</link></item>
+  <item><title>Agent post</title><description>agent</description><link>https://blog.example.com/posts/2024.10001</link></item>
+  <item><title>Agent guid</title><description>agent</description><guid isPermaLink="false">1</guid></item>
+</channel></rss>`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	statePath := filepath.Join(dir, "state.json")
+	content := `topics:
+  - name: Lab
+    source: feed
+    query: "` + server.URL + `/feed"
+    keywords: [agent]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := RunFetch(context.Background(), FetchOptions{ConfigPath: configPath, StatePath: statePath}); err != nil {
+		t.Fatalf("RunFetch: %v", err)
+	}
+
+	st, err := state.New(statePath).Load()
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	want := map[string]bool{
+		"feed:lab.org/news/1234567":                                true,
+		"feed:blog.example.com/posts/2024.10001":                   true,
+		"feed:" + strings.TrimPrefix(server.URL, "http://") + "/1": true,
+	}
+	if len(st.Pending) != len(want) {
+		t.Fatalf("expected %d pending papers, got %#v", len(want), st.Pending)
+	}
+	for _, paper := range st.Pending {
+		if !want[paper.Paper.ID] || paper.Paper.Version != 0 {
+			t.Fatalf("feed IDs should survive unchanged, got %q v%d", paper.Paper.ID, paper.Paper.Version)
+		}
+	}
+}
+
+type failingSeedLookup struct{}
+
+func (failingSeedLookup) FetchByIDs(ctx context.Context, ids []string) ([]model.Paper, error) {
+	return nil, errors.New("unexpected status 503 Service Unavailable")
+}
+
+func TestRunFetchScoresWithoutSeedsWhenTheirLookupFails(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><id>https://papers.cool/arxiv/2602.22094</id><title>Agent memory</title><summary>agent</summary></entry>
+</feed>`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	statePath := filepath.Join(dir, "state.json")
+	content := `topics:
+  - name: Seeded
+    source: paperscool
+    query: "` + server.URL + `/seeded/feed"
+    keywords: [agent]
+    seed_papers: ["2601.00001"]
+  - name: Plain
+    source: paperscool
+    query: "` + server.URL + `/plain/feed"
+    keywords: [memory]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	result, err := RunFetch(context.Background(), FetchOptions{ConfigPath: configPath, StatePath: statePath, seedLookup: failingSeedLookup{}})
+	if err != nil {
+		t.Fatalf("RunFetch should tolerate failing seeds: %v", err)
+	}
+	if result.Statuses[0].Err == nil || !strings.Contains(result.Statuses[0].Err.Error(), "503") || result.Statuses[1].Err != nil {
+		t.Fatalf("expected the seed error on the seeded topic only, got %#v", result.Statuses)
+	}
+
+	st, err := state.New(statePath).Load()
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(st.Pending) != 1 || len(st.Pending[0].Topics) != 2 {
+		t.Fatalf("expected the paper queued by keywords for both topics, got %#v", st.Pending)
+	}
+}
+
+// fakeSeedLookup resolves any arXiv ID to a paper about agent memory.
+type fakeSeedLookup struct{}
+
+func (fakeSeedLookup) FetchByIDs(ctx context.Context, ids []string) ([]model.Paper, error) {
+	papers := make([]model.Paper, len(ids))
+	for i, id := range ids {
+		papers[i] = model.Paper{
+			ID:       id,
+			Title:    "Episodic memory for language agents " + id,
+			Abstract: "Agents plan over long horizons with episodic retrieval, memory consolidation, reflection and tool use.",
+		}
+	}
+	return papers, nil
+}
+
+func TestRunFetchIsDeterministicUnderConcurrency(t *testing.T) {
+	t.Parallel()
+
+	// Each topic's feed answers after a different delay, so topics finish
+	// in a different order than they are configured in.
+	feeds := map[string]struct {
+		delay time.Duration
+		ids   []string
+	}{
+		"/a/feed": {30 * time.Millisecond, []string{"2602.00001", "2602.00002", "2602.00003"}},
+		"/b/feed": {0, []string{"2602.00003", "2602.00004"}},
+		"/c/feed": {15 * time.Millisecond, []string{"2602.00005", "2602.00001"}},
+	}
+	summaries := map[string]string{
+		"2602.00001": "long horizon planning over episodic retrieval and tool use",
+		"2602.00002": "retrieval augmented reasoning for web navigation benchmarks",
+		"2602.00003": "memory consolidation in lifelong embodied agents and skill libraries",
+		"2602.00004": "multi agent debate with shared scratchpads and reflection",
+		"2602.00005": "episodic retrieval for long context dialogue and planning",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed := feeds[r.URL.Path]
+		time.Sleep(feed.delay)
+		body := `<feed xmlns="http://www.w3.org/2005/Atom">`
+		for _, id := range feed.ids {
+			body += "<entry><id>https://papers.cool/arxiv/" + id + "</id><title>agent " + id + "</title><summary>agent memory with " + summaries[id] + "</summary></entry>"
+		}
+		w.Write([]byte(body + "</feed>"))
+	}))
+	defer server.Close()
+
+	content := "concurrency: 3\ntopics:\n"
+	for _, name := range []string{"a", "b", "c"} {
+		content += "  - name: " + name + "\n    source: paperscool\n    query: \"" + server.URL + "/" + name + "/feed\"\n    keywords: [agent, memory]\n"
+	}
+	// A seeded topic adds similarity scores, which must be just as stable.
+	content += "  - name: seeded\n    source: paperscool\n    query: \"" + server.URL + "/a/feed\"\n    keywords: [agent]\n    seed_papers: [\"2601.00001\", \"2601.00002\"]\n"
+
+	run := func() []byte {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "config.yaml")
+		statePath := filepath.Join(dir, "state.json")
+		if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+		if _, err := RunFetch(context.Background(), FetchOptions{ConfigPath: configPath, StatePath: statePath, Now: now, seedLookup: fakeSeedLookup{}}); err != nil {
+			t.Fatalf("RunFetch: %v", err)
+		}
+		data, err := os.ReadFile(statePath)
+		if err != nil {
+			t.Fatalf("read state: %v", err)
+		}
+		return data
+	}
+
+	first, second := run(), run()
+	if string(first) != string(second) {
+		t.Fatalf("state differs between runs:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestRunFetchCheckpointsAnInterruptedHarvest(t *testing.T) {
+	t.Parallel()
+
+	record := func(id string) string {
+		return `<record><header><identifier>oai:arXiv.org:` + id + `</identifier></header><metadata>
+<arXivRaw xmlns="http://arxiv.org/OAI/arXivRaw/"><id>` + id + `</id><title>Agent memory ` + id + `</title><abstract>agent</abstract></arXivRaw></metadata></record>`
+	}
+	var secondPageDown atomic.Bool
+	secondPageDown.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		records, next := record("2603.00001"), "p2"
+		if r.URL.Query().Get("resumptionToken") == "p2" {
+			if secondPageDown.Load() {
+				http.Error(w, "down", http.StatusNotFound)
+				return
+			}
+			records, next = record("2603.00002"), ""
+		}
+		w.Write([]byte(`<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"><ListRecords>` + records +
+			`<resumptionToken>` + next + `</resumptionToken></ListRecords></OAI-PMH>`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	statePath := filepath.Join(dir, "state.json")
+	content := `topics:
+  - name: Backfill
+    source: oai-pmh
+    query: "cs"
+    keywords: [agent]
+    source_options: {endpoint: "` + server.URL + `"}
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	result, err := RunFetch(context.Background(), FetchOptions{ConfigPath: configPath, StatePath: statePath})
+	if err != nil {
+		t.Fatalf("RunFetch: %v", err)
+	}
+	if result.Failed() != 1 || result.Queued != 1 {
+		t.Fatalf("expected the first page queued and the topic failed, got %#v", result)
+	}
+
+	secondPageDown.Store(false)
+	if _, err := RunFetch(context.Background(), FetchOptions{ConfigPath: configPath, StatePath: statePath}); err != nil {
+		t.Fatalf("RunFetch: %v", err)
+	}
+	st, err := state.New(statePath).Load()
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(st.Pending) != 2 || st.Pending[1].Paper.ID != "2603.00002" {
+		t.Fatalf("expected the harvest to resume at the second page, got %#v", st.Pending)
+	}
+	if !strings.Contains(st.Checkpoints["oai-pmh/Backfill"], `"done":true`) {
+		t.Fatalf("expected a finished checkpoint, got %q", st.Checkpoints)
+	}
+}
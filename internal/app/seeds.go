@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/scoring"
+	"github.com/kyc001/paper-radar/internal/seeds"
+)
+
+// attachSeeds resolves every topic's seed_papers through the seed cache in
+// the state directory and attaches a seed set per topic to corpus. Term
+// weights come from this run's papers plus all seeds. It returns the error
+// of each topic whose seeds could not be resolved, keyed by topic name;
+// those topics get no seed set and score without seed similarity.
+func attachSeeds(ctx context.Context, cfg config.Config, configPath, statePath string, lookup seeds.Lookup, papers []model.Paper, corpus *scoring.Corpus) map[string]error {
+	var seeded []config.Topic
+	for _, topic := range cfg.Topics {
+		if len(topic.SeedPapers) > 0 {
+			seeded = append(seeded, topic)
+		}
+	}
+	if len(seeded) == 0 {
+		return nil
+	}
+
+	errs := make(map[string]error)
+	cache, err := seeds.LoadCache(filepath.Join(filepath.Dir(statePath), seeds.CacheFile))
+	if err != nil {
+		for _, topic := range seeded {
+			errs[topic.Name] = err
+		}
+		return errs
+	}
+
+	perTopic := make(map[string][]scoring.Seed)
+	docs := make([]map[string]int, 0, len(papers))
+	for _, topic := range seeded {
+		resolved, err := cache.Resolve(ctx, lookup, topic.SeedPapers, filepath.Dir(configPath))
+		if err != nil {
+			errs[topic.Name] = err
+			continue
+		}
+		perTopic[topic.Name] = resolved
+		for _, seed := range resolved {
+			docs = append(docs, seed.Terms)
+		}
+	}
+
+	// The seeds are resolved either way; an unsaved cache only costs the
+	// lookups again next run.
+	if err := cache.Save(); err != nil {
+		for name := range perTopic {
+			errs[name] = fmt.Errorf("save seed cache: %w", err)
+		}
+	}
+
+	for _, paper := range papers {
+		docs = append(docs, scoring.TermCounts(paper.Title+" "+paper.Abstract))
+	}
+	index := scoring.NewSimilarityIndex(docs...)
+	for name, resolved := range perTopic {
+		corpus.SetSeeds(name, scoring.NewSeedSet(index, resolved))
+	}
+
+	return errs
+}
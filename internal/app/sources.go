@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/source"
+
+	// Built-in sources register themselves.
+	_ "github.com/kyc001/paper-radar/internal/arxiv"
+	_ "github.com/kyc001/paper-radar/internal/feed"
+	_ "github.com/kyc001/paper-radar/internal/oaipmh"
+	_ "github.com/kyc001/paper-radar/internal/paperscool"
+)
+
+// otherHTTPNames are http config keys that are not sources: the notifier
+// and the citation lookup.
+var otherHTTPNames = []string{"citations", "feishu"}
+
+// Registry checks configs against the registered sources; pass it to
+// config.Load so a misspelt source, bad source_options or an unknown http
+// key fail when the config is loaded.
+type Registry struct{}
+
+func (Registry) CheckHTTPName(name string) error {
+	if _, ok := source.Lookup(name); !ok && !slices.Contains(otherHTTPNames, name) {
+		return fmt.Errorf("unknown source %q (want one of %s)", name, strings.Join(append(source.Names(), otherHTTPNames...), ", "))
+	}
+	return nil
+}
+
+// CheckTopic checks the topic's query and options with a source built
+// without a cache or checkpoints; building one does no I/O.
+func (Registry) CheckTopic(topic config.Topic) error {
+	factory, ok := source.Lookup(topic.Source)
+	if !ok {
+		return fmt.Errorf("unknown source %q (want one of %s)", topic.Source, strings.Join(source.Names(), ", "))
+	}
+	src := factory(source.Env{HTTP: func(string) httpclient.Policy { return httpclient.Policy{} }})
+	return src.Check(source.TopicQuery{Topic: topic.Name, Query: topic.Query, Options: topic.SourceOptions})
+}
+
+// loadConfig loads the config at path, checking it against the registered
+// sources.
+func loadConfig(path string) (config.Config, error) {
+	return config.Load(path, Registry{})
+}
+
+// topicSources returns the source of every topic, in config order. Each
+// registered source used is built once and shared between its topics, and
+// every topic's query and options are checked before anything is fetched.
+func topicSources(cfg config.Config, env source.Env, maxResults int, now time.Time) ([]source.Source, []source.TopicQuery, error) {
+	built := make(map[string]source.Source)
+	sources := make([]source.Source, len(cfg.Topics))
+	queries := make([]source.TopicQuery, len(cfg.Topics))
+	for i, topic := range cfg.Topics {
+		src, ok := built[topic.Source]
+		if !ok {
+			factory, found := source.Lookup(topic.Source)
+			if !found {
+				return nil, nil, fmt.Errorf("topic[%d] (%s) unknown source %q (want one of %s)", i, topic.Name, topic.Source, strings.Join(source.Names(), ", "))
+			}
+			src = factory(env)
+			built[topic.Source] = src
+		}
+
+		query := topicQuery(cfg, topic, maxResults, now)
+		if err := src.Check(query); err != nil {
+			return nil, nil, fmt.Errorf("topic[%d] (%s) %w", i, topic.Name, err)
+		}
+		sources[i] = src
+		queries[i] = query
+	}
+	return sources, queries, nil
+}
+
+func topicQuery(cfg config.Config, topic config.Topic, maxResults int, now time.Time) source.TopicQuery {
+	keywords := make([]string, 0, len(topic.Keywords))
+	for _, keyword := range topic.Keywords {
+		keywords = append(keywords, keyword.Word)
+	}
+	return source.TopicQuery{
+		Topic:      topic.Name,
+		Query:      topic.Query,
+		Keywords:   keywords,
+		MaxResults: cfg.EffectiveMaxResults(topic, maxResults),
+		Since:      cfg.EffectiveSince(topic, now),
+		Options:    topic.SourceOptions,
+	}
+}
+
+// kimiSources returns the names of the sources papers.cool has Kimi
+// summaries for.
+func kimiSources(cfg config.Config, sources []source.Source) map[string]bool {
+	names := make(map[string]bool)
+	for i, src := range sources {
+		if src.Capabilities().KimiSummary {
+			names[cfg.Topics[i].Source] = true
+		}
+	}
+	return names
+}
+
+// checkpoints are the state's source checkpoints, shared by the topics
+// fetched concurrently.
+type checkpoints struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newCheckpoints(values map[string]string) *checkpoints {
+	if values == nil {
+		values = make(map[string]string)
+	}
+	return &checkpoints{values: values}
+}
+
+func (c *checkpoints) Checkpoint(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	return value, ok
+}
+
+func (c *checkpoints) SetCheckpoint(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value == "" {
+		delete(c.values, key)
+		return
+	}
+	c.values[key] = value
+}
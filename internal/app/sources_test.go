@@ -0,0 +1,99 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/source"
+)
+
+func TestTopicSourcesSharesOneSourcePerName(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Parse([]byte(`topics:
+  - name: A
+    keywords: [video]
+  - name: B
+    source: paperscool
+    query: "https://papers.cool/arxiv/cs.CV/feed"
+    keywords: [video]
+  - name: C
+    query: "cat:cs.CV"
+    keywords: [agent]
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+
+	sources, queries, err := topicSources(cfg, source.Env{HTTP: cfg.HTTPPolicy}, 20, time.Now())
+	if err != nil {
+		t.Fatalf("topicSources: %v", err)
+	}
+	if sources[0] != sources[2] || sources[0] == sources[1] {
+		t.Fatalf("expected the arxiv topics to share a source, got %#v", sources)
+	}
+	if queries[0].Query != "" || len(queries[0].Keywords) != 1 || queries[0].Keywords[0] != "video" || queries[0].MaxResults != 20 {
+		t.Fatalf("unexpected query %#v", queries[0])
+	}
+	if got := kimiSources(cfg, sources); !got["paperscool"] || got["arxiv"] {
+		t.Fatalf("expected only paperscool to offer Kimi summaries, got %v", got)
+	}
+}
+
+func TestLoadConfigRejectsBadSources(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config string
+		want   string
+	}{
+		"unknown source": {
+			config: "topics:\n  - name: A\n    source: arxive\n    keywords: [video]\n",
+			want:   `topic[0] (A) unknown source "arxive"`,
+		},
+		"options the source does not take": {
+			config: "topics:\n  - name: A\n    source: paperscool\n    query: x\n    keywords: [video]\n    source_options: {limit: 3}\n",
+			want:   "topic[0] (A) source takes no source_options",
+		},
+		"bad query": {
+			config: "topics:\n  - name: A\n    source: feed\n    query: lab.example/rss\n    keywords: [video]\n",
+			want:   "topic[0] (A) query must be the feed's http(s) URL",
+		},
+		"unknown http key": {
+			config: "http:\n  arxive: {attempts: 2}\ntopics:\n  - name: A\n    keywords: [video]\n",
+			want:   `http: unknown source "arxive"`,
+		},
+	}
+	for name, tc := range cases {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(tc.config), 0o644); err != nil {
+			t.Fatalf("%s: write config: %v", name, err)
+		}
+		_, err := loadConfig(path)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestLoadConfigAcceptsBundledConfigs(t *testing.T) {
+	t.Parallel()
+
+	paths, err := filepath.Glob(filepath.Join("..", "..", "configs", "*.yaml"))
+	if err != nil {
+		t.Fatalf("glob configs: %v", err)
+	}
+	paths = append(paths, filepath.Join("..", "..", "config.example.yaml"))
+	for _, path := range paths {
+		if _, err := loadConfig(path); err != nil {
+			t.Fatalf("load %s: %v", path, err)
+		}
+	}
+}
@@ -7,87 +7,281 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/kyc001/paper-radar/internal/httpclient"
 	"github.com/kyc001/paper-radar/internal/model"
 )
 
-const defaultBaseURL = "https://export.arxiv.org/api/query"
+const (
+	// APIHost serves the search API.
+	APIHost        = "export.arxiv.org"
+	defaultBaseURL = "https://" + APIHost + "/api/query"
+
+	// defaultPageSize is how many entries one API request asks for. arXiv
+	// asks clients to slice large result sets rather than request them at
+	// once.
+	defaultPageSize = 100
+
+	// minRequestInterval spaces API requests the way arXiv's terms of use
+	// ask.
+	minRequestInterval = 3 * time.Second
+)
+
+// APILimiter spaces every request to APIHost by minRequestInterval. Every
+// Client shares it so topics fetched back to back (or concurrently) still
+// respect the interval, as does the OAI-PMH harvester when pointed there.
+var APILimiter = httpclient.NewLimiter(minRequestInterval)
 
 type Client struct {
-	httpClient *http.Client
+	httpClient *httpclient.Client
 	baseURL    string
+	pageSize   int
+	limiter    *httpclient.Limiter
+}
+
+// NewClient returns a client retrying under policy. Every attempt, retries
+// included, waits its turn on the shared rate limiter, and unless policy
+// says otherwise only one request to arXiv is in flight at a time.
+func NewClient(policy httpclient.Policy) *Client {
+	if policy.MaxPerHost <= 0 {
+		policy.MaxPerHost = 1
+	}
+	c := &Client{
+		baseURL:  defaultBaseURL,
+		pageSize: defaultPageSize,
+		limiter:  APILimiter,
+	}
+	policy.Wait = func(ctx context.Context) error { return c.limiter.Wait(ctx) }
+	c.httpClient = httpclient.New("arxiv", policy)
+	return c
 }
 
-func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{Timeout: 20 * time.Second},
-		baseURL:    defaultBaseURL,
+// Fetch returns up to maxResults of the newest papers matching query,
+// paging through the API with start. When since is non-zero, paging stops
+// at the first paper submitted before it and older papers are dropped.
+func (c *Client) Fetch(ctx context.Context, query string, maxResults int, since time.Time) ([]model.Paper, error) {
+	var papers []model.Paper
+	for start := 0; start < maxResults; {
+		params := url.Values{}
+		params.Set("search_query", query)
+		params.Set("sortBy", "submittedDate")
+		params.Set("sortOrder", "descending")
+		params.Set("start", strconv.Itoa(start))
+		size := min(c.pageSize, maxResults-start)
+		params.Set("max_results", strconv.Itoa(size))
+
+		page, err := c.query(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("fetch results from %d: %w", start, err)
+		}
+
+		reachedCutoff := false
+		for _, paper := range page.papers {
+			if !since.IsZero() && !paper.PublishedAt.IsZero() && paper.PublishedAt.Before(since) {
+				reachedCutoff = true
+				break
+			}
+			papers = append(papers, paper)
+		}
+
+		start += len(page.papers)
+		if reachedCutoff || len(page.papers) == 0 {
+			break
+		}
+		// arXiv occasionally returns a short page mid-way through, so
+		// only trust a short page as the end without totalResults.
+		if page.total >= 0 && start >= page.total || page.total < 0 && len(page.papers) < size {
+			break
+		}
+	}
+
+	if len(papers) > maxResults {
+		papers = papers[:maxResults]
 	}
+	return papers, nil
 }
 
-func (c *Client) Fetch(ctx context.Context, query string, maxResults int) ([]model.Paper, error) {
+// FetchByIDs looks papers up by arXiv ID (e.g. "2602.23153").
+func (c *Client) FetchByIDs(ctx context.Context, ids []string) ([]model.Paper, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
 	params := url.Values{}
-	params.Set("search_query", query)
-	params.Set("sortBy", "submittedDate")
-	params.Set("sortOrder", "descending")
-	params.Set("max_results", fmt.Sprintf("%d", maxResults))
+	params.Set("id_list", strings.Join(ids, ","))
+	params.Set("max_results", strconv.Itoa(len(ids)))
 
+	page, err := c.query(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return page.papers, nil
+}
+
+// feedPage is one API response. total is opensearch:totalResults, or -1
+// when the response did not report it.
+type feedPage struct {
+	papers []model.Paper
+	total  int
+}
+
+func (c *Client) query(ctx context.Context, params url.Values) (feedPage, error) {
 	endpoint := c.baseURL + "?" + params.Encode()
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return nil, err
+		return feedPage{}, err
 	}
 	request.Header.Set("User-Agent", "paper-radar/0.1.0")
 
 	response, err := c.httpClient.Do(request)
 	if err != nil {
-		return nil, err
+		return feedPage{}, err
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
-		return nil, fmt.Errorf("unexpected status %s: %s", response.Status, strings.TrimSpace(string(body)))
+		return feedPage{}, fmt.Errorf("unexpected status %s: %s", response.Status, strings.TrimSpace(string(body)))
 	}
 
 	var feed atomFeed
 	if err := xml.NewDecoder(response.Body).Decode(&feed); err != nil {
-		return nil, err
+		return feedPage{}, err
 	}
 
-	papers := make([]model.Paper, 0, len(feed.Entries))
+	page := feedPage{papers: make([]model.Paper, 0, len(feed.Entries)), total: -1}
+	if total, err := strconv.Atoi(strings.TrimSpace(feed.TotalResults)); err == nil {
+		page.total = total
+	}
 	for _, entry := range feed.Entries {
-		papers = append(papers, model.Paper{
-			ID:          strings.TrimSpace(entry.ID),
-			Title:       normalizeWhitespace(entry.Title),
-			Summary:     normalizeWhitespace(entry.Summary),
-			URL:         entry.URL(),
-			PublishedAt: parseTime(entry.Published),
-			UpdatedAt:   parseTime(entry.Updated),
+		arxivID, isArxiv := model.ParseArxivID(entry.ID)
+		id, version := model.CanonicalID(entry.ID)
+		primary, categories := entry.categories()
+		page.papers = append(page.papers, model.Paper{
+			ID:              id,
+			Version:         version,
+			Title:           normalizeWhitespace(entry.Title),
+			Abstract:        normalizeWhitespace(entry.Summary),
+			URL:             entry.URL(),
+			PublishedAt:     parseTime(entry.Published),
+			UpdatedAt:       parseTime(entry.Updated),
+			Authors:         entry.authors(),
+			Affiliations:    entry.affiliations(),
+			PrimaryCategory: primary,
+			Categories:      categories,
+			Comment:         normalizeWhitespace(entry.Comment),
+			JournalRef:      normalizeWhitespace(entry.JournalRef),
+			DOI:             strings.TrimSpace(entry.DOI),
+			PDFURL:          entry.pdfURL(arxivID, isArxiv),
 		})
 	}
 
-	return papers, nil
+	return page, nil
 }
 
 type atomFeed struct {
-	Entries []atomEntry `xml:"entry"`
+	TotalResults string      `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	Entries      []atomEntry `xml:"entry"`
 }
 
+// Elements in the http://arxiv.org/schemas/atom namespace are arXiv's
+// extensions (arxiv:comment etc.).
 type atomEntry struct {
-	ID        string     `xml:"id"`
-	Title     string     `xml:"title"`
-	Summary   string     `xml:"summary"`
-	Published string     `xml:"published"`
-	Updated   string     `xml:"updated"`
-	Links     []atomLink `xml:"link"`
+	ID              string         `xml:"id"`
+	Title           string         `xml:"title"`
+	Summary         string         `xml:"summary"`
+	Published       string         `xml:"published"`
+	Updated         string         `xml:"updated"`
+	Links           []atomLink     `xml:"link"`
+	Authors         []atomAuthor   `xml:"author"`
+	PrimaryCategory atomCategory   `xml:"http://arxiv.org/schemas/atom primary_category"`
+	Categories      []atomCategory `xml:"category"`
+	Comment         string         `xml:"http://arxiv.org/schemas/atom comment"`
+	JournalRef      string         `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	DOI             string         `xml:"http://arxiv.org/schemas/atom doi"`
 }
 
 type atomLink struct {
-	Rel  string `xml:"rel,attr"`
-	Href string `xml:"href,attr"`
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type atomAuthor struct {
+	Name         string   `xml:"name"`
+	Affiliations []string `xml:"http://arxiv.org/schemas/atom affiliation"`
+}
+
+// affiliations returns the authors' distinct affiliations in author order.
+func (e atomEntry) affiliations() []string {
+	var affiliations []string
+	seen := make(map[string]bool)
+	for _, author := range e.Authors {
+		for _, affiliation := range author.Affiliations {
+			if affiliation = normalizeWhitespace(affiliation); affiliation != "" && !seen[affiliation] {
+				seen[affiliation] = true
+				affiliations = append(affiliations, affiliation)
+			}
+		}
+	}
+	return affiliations
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func (e atomEntry) authors() []string {
+	var names []string
+	for _, author := range e.Authors {
+		if name := normalizeWhitespace(author.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// categories returns the entry's category terms, primary first.
+func (e atomEntry) categories() (string, []string) {
+	primary := strings.TrimSpace(e.PrimaryCategory.Term)
+	var terms []string
+	seen := map[string]bool{}
+	for _, term := range append([]string{primary}, categoryTerms(e.Categories)...) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	if primary == "" && len(terms) > 0 {
+		primary = terms[0]
+	}
+	return primary, terms
+}
+
+func categoryTerms(categories []atomCategory) []string {
+	terms := make([]string, 0, len(categories))
+	for _, category := range categories {
+		terms = append(terms, strings.TrimSpace(category.Term))
+	}
+	return terms
+}
+
+// pdfURL returns the entry's PDF link, falling back to arXiv's PDF URL for
+// the given ID.
+func (e atomEntry) pdfURL(id model.ArxivID, isArxiv bool) string {
+	for _, link := range e.Links {
+		if link.Href != "" && (link.Title == "pdf" || link.Type == "application/pdf") {
+			return link.Href
+		}
+	}
+	if isArxiv {
+		return "https://arxiv.org/pdf/" + id.Versioned()
+	}
+	return ""
 }
 
 func (e atomEntry) URL() string {
@@ -0,0 +1,197 @@
+package arxiv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/httpclient"
+)
+
+const sampleFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2602.23153v2</id>
+    <updated>2026-02-27T10:00:00Z</updated>
+    <published>2026-02-26T18:00:00Z</published>
+    <title>Streaming 4D
+      Reconstruction</title>
+    <summary>  We reconstruct dynamic scenes.  </summary>
+    <author><name>Ada Lovelace</name><arxiv:affiliation>Analytical Engines</arxiv:affiliation></author>
+    <author><name>Alan  Turing</name></author>
+    <arxiv:comment>Accepted at CVPR 2026. Code: https://github.com/example/s4d</arxiv:comment>
+    <arxiv:journal_ref>CVPR 2026</arxiv:journal_ref>
+    <arxiv:doi>10.1000/s4d.2026</arxiv:doi>
+    <link href="http://arxiv.org/abs/2602.23153v2" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/2602.23153v2" rel="related" type="application/pdf"/>
+    <arxiv:primary_category term="cs.CV" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.CV" scheme="http://arxiv.org/schemas/atom"/>
+    <category term="cs.GR" scheme="http://arxiv.org/schemas/atom"/>
+  </entry>
+</feed>
+`
+
+func TestFetchParsesEntryMetadata(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("search_query"); got != "cat:cs.CV" {
+			t.Errorf("unexpected search_query %q", got)
+		}
+		w.Write([]byte(sampleFeed))
+	}))
+	defer server.Close()
+
+	client := NewClient(httpclient.Policy{})
+	client.baseURL = server.URL
+	client.limiter = httpclient.NewLimiter(0)
+
+	papers, err := client.Fetch(context.Background(), "cat:cs.CV", 10, time.Time{})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(papers) != 1 {
+		t.Fatalf("expected 1 paper, got %d", len(papers))
+	}
+
+	paper := papers[0]
+	if paper.ID != "2602.23153" || paper.Version != 2 || paper.Title != "Streaming 4D Reconstruction" {
+		t.Fatalf("unexpected identity: %#v", paper)
+	}
+	if !paper.UpdatedAt.Equal(time.Date(2026, 2, 27, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected updated time %v", paper.UpdatedAt)
+	}
+	if len(paper.Authors) != 2 || paper.Authors[0] != "Ada Lovelace" || paper.Authors[1] != "Alan Turing" {
+		t.Fatalf("unexpected authors %q", paper.Authors)
+	}
+	if len(paper.Affiliations) != 1 || paper.Affiliations[0] != "Analytical Engines" {
+		t.Fatalf("unexpected affiliations %q", paper.Affiliations)
+	}
+	if paper.PrimaryCategory != "cs.CV" || len(paper.Categories) != 2 || paper.Categories[1] != "cs.GR" {
+		t.Fatalf("unexpected categories %q / %q", paper.PrimaryCategory, paper.Categories)
+	}
+	if paper.Comment != "Accepted at CVPR 2026. Code: https://github.com/example/s4d" || paper.JournalRef != "CVPR 2026" || paper.DOI != "10.1000/s4d.2026" {
+		t.Fatalf("unexpected comment/journal/doi: %#v", paper)
+	}
+	if paper.PDFURL != "http://arxiv.org/pdf/2602.23153v2" {
+		t.Fatalf("unexpected pdf url %q", paper.PDFURL)
+	}
+}
+
+// pagedServer serves total papers, newest first, one day apart from
+// 2026-03-01, honouring start and max_results. It records each request's
+// start.
+func pagedServer(t *testing.T, total int) (*httptest.Server, *[]int) {
+	var mu sync.Mutex
+	var starts []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("max_results"))
+		mu.Lock()
+		starts = append(starts, start)
+		mu.Unlock()
+
+		var b strings.Builder
+		b.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/">`)
+		fmt.Fprintf(&b, "<opensearch:totalResults>%d</opensearch:totalResults>", total)
+		for i := start; i < start+size && i < total; i++ {
+			published := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -i)
+			fmt.Fprintf(&b, "<entry><id>http://arxiv.org/abs/2603.%05dv1</id><title>Paper %d</title><published>%s</published></entry>", i, i, published.Format(time.RFC3339))
+		}
+		b.WriteString("</feed>")
+		w.Write([]byte(b.String()))
+	}))
+	t.Cleanup(server.Close)
+	return server, &starts
+}
+
+func TestFetchPagesWithStart(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		total      int
+		maxResults int
+		want       int
+		wantStarts []int
+	}{
+		{"stops at max_results", 100, 5, 5, []int{0, 2, 4}},
+		{"stops at totalResults", 3, 10, 3, []int{0, 2}},
+	}
+
+	for _, tc := range cases {
+		server, starts := pagedServer(t, tc.total)
+		client := NewClient(httpclient.Policy{})
+		client.baseURL = server.URL
+		client.pageSize = 2
+		client.limiter = httpclient.NewLimiter(0)
+
+		papers, err := client.Fetch(context.Background(), "cat:cs.CV", tc.maxResults, time.Time{})
+		if err != nil {
+			t.Fatalf("%s: fetch: %v", tc.name, err)
+		}
+		if len(papers) != tc.want {
+			t.Fatalf("%s: expected %d papers, got %d", tc.name, tc.want, len(papers))
+		}
+		if fmt.Sprint(*starts) != fmt.Sprint(tc.wantStarts) {
+			t.Fatalf("%s: expected starts %v, got %v", tc.name, tc.wantStarts, *starts)
+		}
+	}
+}
+
+func TestFetchStopsAtDateCutoff(t *testing.T) {
+	t.Parallel()
+
+	server, starts := pagedServer(t, 100)
+	client := NewClient(httpclient.Policy{})
+	client.baseURL = server.URL
+	client.pageSize = 2
+	client.limiter = httpclient.NewLimiter(0)
+
+	// Papers 0..2 fall on or after Feb 27; paper 3 (Feb 26) ends paging.
+	since := time.Date(2026, 2, 27, 0, 0, 0, 0, time.UTC)
+	papers, err := client.Fetch(context.Background(), "cat:cs.CV", 50, since)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(papers) != 3 || papers[2].ID != "2603.00002" {
+		t.Fatalf("expected the 3 papers since the cutoff, got %#v", papers)
+	}
+	if len(*starts) != 2 {
+		t.Fatalf("expected paging to stop after the cutoff page, got starts %v", *starts)
+	}
+}
+
+func TestFetchRetriesUnavailable(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(sampleFeed))
+	}))
+	defer server.Close()
+
+	client := NewClient(httpclient.Policy{Attempts: 3})
+	client.baseURL = server.URL
+	client.limiter = httpclient.NewLimiter(0)
+
+	papers, err := client.Fetch(context.Background(), "cat:cs.CV", 10, time.Time{})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(papers) != 1 || calls != 3 {
+		t.Fatalf("expected success on the third attempt, got %d papers after %d calls", len(papers), calls)
+	}
+}
@@ -0,0 +1,161 @@
+package arxiv
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+// defaultListingURL serves the per-category announcement RSS, e.g.
+// https://rss.arxiv.org/rss/cs.CV or, for several categories at once,
+// https://rss.arxiv.org/rss/cs.CV+cs.LG.
+const defaultListingURL = "https://rss.arxiv.org/rss/"
+
+// ListingClient reads arXiv's daily announcement feeds. Unlike the search
+// API they list exactly what the day's "new" mailing announces, cross-lists
+// and replacements included.
+type ListingClient struct {
+	httpClient *httpclient.Client
+	baseURL    string
+}
+
+func NewListingClient(policy httpclient.Policy) *ListingClient {
+	return &ListingClient{
+		httpClient: httpclient.New("arxiv-listing", policy),
+		baseURL:    defaultListingURL,
+	}
+}
+
+// FetchListing returns today's announcements for categories ("cs.CV", or
+// "cs.CV+cs.LG"), each tagged with its announcement type. A full URL is
+// fetched as is.
+func (c *ListingClient) FetchListing(ctx context.Context, categories string) ([]model.Paper, error) {
+	endpoint := strings.TrimSpace(categories)
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = c.baseURL + endpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "paper-radar/0.1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var feed listingFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	papers := make([]model.Paper, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		arxivID, ok := model.ParseArxivID(item.GUID)
+		if !ok {
+			if arxivID, ok = model.ParseArxivID(item.Link); !ok {
+				continue
+			}
+		}
+		abstract, announced := item.abstract()
+		announcement := strings.TrimSpace(item.AnnounceType)
+		if announcement == "" {
+			announcement = announced
+		}
+		categories := categoryNames(item.Categories)
+		paper := model.Paper{
+			ID:           arxivID.Base,
+			Version:      arxivID.Version,
+			Title:        normalizeWhitespace(item.Title),
+			Abstract:     abstract,
+			URL:          strings.TrimSpace(item.Link),
+			PublishedAt:  parseRSSTime(item.PubDate),
+			Authors:      splitCreators(item.Creator),
+			Categories:   categories,
+			PDFURL:       "https://arxiv.org/pdf/" + arxivID.Versioned(),
+			Announcement: announcement,
+		}
+		if len(categories) > 0 {
+			paper.PrimaryCategory = categories[0]
+		}
+		papers = append(papers, paper)
+	}
+	return papers, nil
+}
+
+type listingFeed struct {
+	Items []listingItem `xml:"channel>item"`
+}
+
+type listingItem struct {
+	Title        string   `xml:"title"`
+	Link         string   `xml:"link"`
+	Description  string   `xml:"description"`
+	GUID         string   `xml:"guid"`
+	Categories   []string `xml:"category"`
+	PubDate      string   `xml:"pubDate"`
+	AnnounceType string   `xml:"http://arxiv.org/schemas/atom announce_type"`
+	Creator      string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+}
+
+// announceRe matches the header arXiv puts before the abstract in a
+// listing item's description: "arXiv:2603.01234v1 Announce Type: new
+// Abstract: ...".
+var announceRe = regexp.MustCompile(`(?s)^\s*arXiv:\S+\s+Announce Type:\s*(\S+)\s+Abstract:\s*`)
+
+// abstract returns the description without its header, and the
+// announcement type the header names.
+func (i listingItem) abstract() (string, string) {
+	m := announceRe.FindStringSubmatchIndex(i.Description)
+	if m == nil {
+		return normalizeWhitespace(i.Description), ""
+	}
+	return normalizeWhitespace(i.Description[m[1]:]), i.Description[m[2]:m[3]]
+}
+
+func categoryNames(categories []string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, category := range categories {
+		if category = strings.TrimSpace(category); category != "" && !seen[category] {
+			seen[category] = true
+			names = append(names, category)
+		}
+	}
+	return names
+}
+
+// splitCreators splits the listing's single comma-separated author list.
+func splitCreators(creator string) []string {
+	var names []string
+	for _, name := range strings.Split(normalizeWhitespace(creator), ",") {
+		name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "and "))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func parseRSSTime(value string) time.Time {
+	parsed, err := time.Parse(time.RFC1123Z, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
@@ -0,0 +1,152 @@
+package arxiv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/source"
+	"gopkg.in/yaml.v3"
+)
+
+const sampleListing = `<?xml version='1.0' encoding='UTF-8'?>
+<rss xmlns:arxiv="http://arxiv.org/schemas/atom" xmlns:dc="http://purl.org/dc/elements/1.1/" version="2.0">
+  <channel>
+    <title>cs.CV updates on arXiv.org</title>
+    <item>
+      <title>Streaming 4D Reconstruction</title>
+      <link>https://arxiv.org/abs/2603.01234</link>
+      <description>arXiv:2603.01234v1 Announce Type: new 
+Abstract: We reconstruct   dynamic scenes.</description>
+      <guid isPermaLink="false">oai:arXiv.org:2603.01234v1</guid>
+      <category>cs.CV</category>
+      <category>cs.GR</category>
+      <pubDate>Mon, 02 Mar 2026 00:00:00 -0500</pubDate>
+      <arxiv:announce_type>new</arxiv:announce_type>
+      <dc:creator>Grace Hopper, Alan Turing, and Ada Lovelace</dc:creator>
+    </item>
+    <item>
+      <title>Agent Memory</title>
+      <link>https://arxiv.org/abs/2603.00042</link>
+      <description>arXiv:2603.00042v1 Announce Type: cross 
+Abstract: Cross-listed from cs.AI.</description>
+      <guid isPermaLink="false">oai:arXiv.org:2603.00042v1</guid>
+      <category>cs.AI</category>
+      <category>cs.CV</category>
+      <arxiv:announce_type>cross</arxiv:announce_type>
+      <dc:creator>Edsger Dijkstra</dc:creator>
+    </item>
+    <item>
+      <title>Older Paper, Revised</title>
+      <link>https://arxiv.org/abs/2601.00007</link>
+      <description>arXiv:2601.00007v3 Announce Type: replace 
+Abstract: Now with more experiments.</description>
+      <guid isPermaLink="false">oai:arXiv.org:2601.00007v3</guid>
+      <category>cs.CV</category>
+    </item>
+    <item>
+      <title>Revised Cross-list</title>
+      <link>https://arxiv.org/abs/2601.00008</link>
+      <description>arXiv:2601.00008v2 Announce Type: replace-cross 
+Abstract: Revised.</description>
+      <guid isPermaLink="false">oai:arXiv.org:2601.00008v2</guid>
+      <arxiv:announce_type>replace-cross</arxiv:announce_type>
+    </item>
+  </channel>
+</rss>`
+
+func listingServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rss/cs.CV+cs.LG" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		w.Write([]byte(sampleListing))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchListingTagsAnnouncements(t *testing.T) {
+	t.Parallel()
+
+	server := listingServer(t)
+	client := NewListingClient(httpclient.Policy{Attempts: 1})
+	client.baseURL = server.URL + "/rss/"
+
+	papers, err := client.FetchListing(context.Background(), "cs.CV+cs.LG")
+	if err != nil {
+		t.Fatalf("fetch listing: %v", err)
+	}
+	if len(papers) != 4 {
+		t.Fatalf("expected 4 papers, got %d", len(papers))
+	}
+
+	first := papers[0]
+	if first.ID != "2603.01234" || first.Version != 1 || first.Announcement != model.AnnounceNew {
+		t.Fatalf("unexpected first paper %#v", first)
+	}
+	if first.Abstract != "We reconstruct dynamic scenes." || first.PrimaryCategory != "cs.CV" || first.PublishedAt.IsZero() {
+		t.Fatalf("unexpected first paper metadata %#v", first)
+	}
+	if len(first.Authors) != 3 || first.Authors[2] != "Ada Lovelace" {
+		t.Fatalf("unexpected authors %q", first.Authors)
+	}
+	// The third item has no announce_type element; the description says.
+	if papers[2].Announcement != model.AnnounceReplace || papers[2].Version != 3 {
+		t.Fatalf("expected the replacement to be tagged from its description, got %#v", papers[2])
+	}
+}
+
+func TestListingSourceFiltersByOptions(t *testing.T) {
+	t.Parallel()
+
+	server := listingServer(t)
+	client := NewListingClient(httpclient.Policy{Attempts: 1})
+	client.baseURL = server.URL + "/rss/"
+	src := &listingSource{client: client}
+
+	cases := []struct {
+		options string
+		want    []string
+	}{
+		{"", []string{"2603.01234", "2603.00042"}},
+		{"cross_lists: false", []string{"2603.01234"}},
+		{"replacements: true", []string{"2603.01234", "2603.00042", "2601.00007", "2601.00008"}},
+		{"{cross_lists: false, replacements: true}", []string{"2603.01234", "2601.00007"}},
+	}
+	for _, tc := range cases {
+		var options source.Options
+		if tc.options != "" {
+			if err := yaml.Unmarshal([]byte(tc.options), &options); err != nil {
+				t.Fatalf("unmarshal %q: %v", tc.options, err)
+			}
+		}
+		q := source.TopicQuery{Query: "cs.CV+cs.LG", Options: options}
+		if err := src.Check(q); err != nil {
+			t.Fatalf("check %q: %v", tc.options, err)
+		}
+		papers, err := src.Fetch(context.Background(), q)
+		if err != nil {
+			t.Fatalf("fetch %q: %v", tc.options, err)
+		}
+		var got []string
+		for _, paper := range papers {
+			got = append(got, paper.ID)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("options %q: got %v, want %v", tc.options, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("options %q: got %v, want %v", tc.options, got, tc.want)
+			}
+		}
+	}
+
+	if err := src.Check(source.TopicQuery{Query: "cs CV"}); err == nil {
+		t.Fatalf("expected a query with spaces to be rejected")
+	}
+}
@@ -0,0 +1,117 @@
+package arxiv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/source"
+)
+
+func init() {
+	source.Register("arxiv", func(env source.Env) source.Source {
+		return &topicSource{client: NewClient(env.HTTP("arxiv"))}
+	})
+	source.Register("arxiv-listing", func(env source.Env) source.Source {
+		return &listingSource{client: NewListingClient(env.HTTP("arxiv-listing"))}
+	})
+}
+
+// topicSource fetches topics through the arXiv search API.
+type topicSource struct {
+	client *Client
+}
+
+func (s *topicSource) Check(q source.TopicQuery) error {
+	if q.Query == "" && len(q.Keywords) == 0 {
+		return fmt.Errorf("needs a query when it has no keywords")
+	}
+	return source.NoOptions(q)
+}
+
+// Fetch searches for q.Query, or for any of the keywords when the topic has
+// no query.
+func (s *topicSource) Fetch(ctx context.Context, q source.TopicQuery) ([]model.Paper, error) {
+	return s.client.Fetch(ctx, searchQuery(q), q.MaxResults, q.Since)
+}
+
+func (s *topicSource) Capabilities() source.Capabilities {
+	return source.Capabilities{DateCutoff: true}
+}
+
+func searchQuery(q source.TopicQuery) string {
+	if q.Query != "" {
+		return q.Query
+	}
+	parts := make([]string, 0, len(q.Keywords))
+	for _, keyword := range q.Keywords {
+		parts = append(parts, fmt.Sprintf("all:\"%s\"", keyword))
+	}
+	return strings.Join(parts, " OR ")
+}
+
+// ListingOptions are the arxiv-listing source's source_options. Cross-lists
+// are kept and replacements dropped unless a topic says otherwise.
+type ListingOptions struct {
+	CrossLists   *bool `yaml:"cross_lists"`
+	Replacements *bool `yaml:"replacements"`
+}
+
+// keeps reports whether a paper announced as announcement passes the
+// options. A replaced cross-list needs both kinds enabled.
+func (o ListingOptions) keeps(announcement string) bool {
+	crossLists := o.CrossLists == nil || *o.CrossLists
+	replacements := o.Replacements != nil && *o.Replacements
+	switch announcement {
+	case model.AnnounceCross:
+		return crossLists
+	case model.AnnounceReplace:
+		return replacements
+	case model.AnnounceReplaceCross:
+		return crossLists && replacements
+	}
+	return true
+}
+
+// listingSource fetches topics from the daily announcement feed of the
+// categories in the topic's query.
+type listingSource struct {
+	client *ListingClient
+}
+
+func (s *listingSource) Check(q source.TopicQuery) error {
+	if strings.TrimSpace(q.Query) == "" || strings.ContainsAny(strings.TrimSpace(q.Query), " \t") {
+		return fmt.Errorf("query must be a category like cs.CV (join several with +), got %q", q.Query)
+	}
+	var opts ListingOptions
+	return q.Options.Decode(&opts)
+}
+
+// Fetch returns the listing's papers in announcement order, filtered by the
+// topic's options and capped at q.MaxResults.
+func (s *listingSource) Fetch(ctx context.Context, q source.TopicQuery) ([]model.Paper, error) {
+	var opts ListingOptions
+	if err := q.Options.Decode(&opts); err != nil {
+		return nil, err
+	}
+	listed, err := s.client.FetchListing(ctx, q.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var papers []model.Paper
+	for _, paper := range listed {
+		if q.MaxResults > 0 && len(papers) >= q.MaxResults {
+			break
+		}
+		if opts.keeps(paper.Announcement) {
+			papers = append(papers, paper)
+		}
+	}
+	return papers, nil
+}
+
+func (s *listingSource) Capabilities() source.Capabilities {
+	return source.Capabilities{}
+}
@@ -0,0 +1,252 @@
+// Package cache is an on-disk content cache for per-paper enrichments
+// (Kimi summaries and the like), kept under the state directory so a fresh
+// state or a crashed run does not download them again. Entries are keyed
+// by enrichment kind and canonical paper ID, expire after a TTL, and the
+// oldest are pruned once the cache outgrows its size limit.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName is the cache's directory name inside the state directory.
+const DirName = "cache"
+
+const (
+	// DefaultTTL is how long an entry is served when Options.TTL is unset.
+	DefaultTTL = 30 * 24 * time.Hour
+	// DefaultMaxBytes bounds the cache when Options.MaxBytes is unset.
+	DefaultMaxBytes = 100 << 20
+)
+
+// Options sets the cache's limits. Zero fields take the defaults.
+type Options struct {
+	TTL      time.Duration
+	MaxBytes int64
+}
+
+// Cache stores one file per entry at <dir>/<kind>/<hash of id>.json. A nil
+// *Cache is valid and caches nothing, so enrichers can read through it
+// unconditionally.
+type Cache struct {
+	dir  string
+	opts Options
+	now  func() time.Time
+}
+
+// entry is the file format of one cached value.
+type entry struct {
+	ID       string    `json:"id"`
+	StoredAt time.Time `json:"stored_at"`
+	Value    string    `json:"value"`
+}
+
+// Open returns the cache rooted at dir; the directory is created on the
+// first Put.
+func Open(dir string, opts Options) *Cache {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	return &Cache{dir: dir, opts: opts, now: time.Now}
+}
+
+// Dir is where the cache keeps its files.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Get returns the value cached for kind and id, if present and fresh.
+func (c *Cache) Get(kind, id string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	cached, err := readEntry(c.path(kind, id))
+	if err != nil || cached.ID != id || c.expired(cached) {
+		return "", false
+	}
+	return cached.Value, true
+}
+
+// Put stores value for kind and id, replacing any earlier value.
+func (c *Cache) Put(kind, id, value string) error {
+	if c == nil {
+		return nil
+	}
+	path := c.path(kind, id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{ID: id, StoredAt: c.now().UTC(), Value: value})
+	if err != nil {
+		return err
+	}
+
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, path)
+}
+
+// KindStats summarizes the entries of one kind.
+type KindStats struct {
+	Kind    string
+	Entries int
+	Bytes   int64
+	Expired int
+}
+
+// Stats summarizes the cache per kind, sorted by kind.
+func (c *Cache) Stats() ([]KindStats, error) {
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+
+	byKind := map[string]*KindStats{}
+	for _, file := range files {
+		stats, ok := byKind[file.kind]
+		if !ok {
+			stats = &KindStats{Kind: file.kind}
+			byKind[file.kind] = stats
+		}
+		stats.Entries++
+		stats.Bytes += file.size
+		if file.expired {
+			stats.Expired++
+		}
+	}
+
+	result := make([]KindStats, 0, len(byKind))
+	for _, stats := range byKind {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Kind < result[j].Kind })
+	return result, nil
+}
+
+// PruneResult reports what Prune removed.
+type PruneResult struct {
+	Expired int
+	Evicted int
+	Bytes   int64
+}
+
+// Prune deletes expired and unreadable entries, then the oldest ones until
+// the cache fits in its size limit.
+func (c *Cache) Prune() (PruneResult, error) {
+	files, err := c.files()
+	if err != nil {
+		return PruneResult{}, err
+	}
+
+	var result PruneResult
+	var kept []cacheFile
+	var total int64
+	for _, file := range files {
+		if !file.expired {
+			kept = append(kept, file)
+			total += file.size
+			continue
+		}
+		if err := os.Remove(file.path); err != nil {
+			return result, err
+		}
+		result.Expired++
+		result.Bytes += file.size
+	}
+
+	sort.Slice(kept, func(i, j int) bool { return kept[i].storedAt.Before(kept[j].storedAt) })
+	for _, file := range kept {
+		if total <= c.opts.MaxBytes {
+			break
+		}
+		if err := os.Remove(file.path); err != nil {
+			return result, err
+		}
+		total -= file.size
+		result.Evicted++
+		result.Bytes += file.size
+	}
+	return result, nil
+}
+
+// cacheFile is an entry as found on disk; unreadable entries count as
+// expired.
+type cacheFile struct {
+	path     string
+	kind     string
+	size     int64
+	storedAt time.Time
+	expired  bool
+}
+
+func (c *Cache) files() ([]cacheFile, error) {
+	var files []cacheFile
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == c.dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		file := cacheFile{path: path, kind: filepath.Base(filepath.Dir(path)), size: info.Size()}
+		cached, err := readEntry(path)
+		if err != nil {
+			file.expired = true
+		} else {
+			file.storedAt = cached.StoredAt
+			file.expired = c.expired(cached)
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan cache: %w", err)
+	}
+	return files, nil
+}
+
+func (c *Cache) expired(cached entry) bool {
+	return c.now().Sub(cached.StoredAt) > c.opts.TTL
+}
+
+// path hashes id so any ID, including old-style arXiv IDs with a slash,
+// makes a safe file name.
+func (c *Cache) path(kind, id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(c.dir, kind, hex.EncodeToString(sum[:16])+".json")
+}
+
+func readEntry(path string) (entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry{}, err
+	}
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return entry{}, err
+	}
+	return cached, nil
+}
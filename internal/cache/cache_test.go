@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetPutAndTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	c := Open(t.TempDir(), Options{TTL: 24 * time.Hour})
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Get("kimi", "hep-th/9901001"); ok {
+		t.Fatalf("expected a miss on an empty cache")
+	}
+	if err := c.Put("kimi", "hep-th/9901001", "summary"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if got, ok := c.Get("kimi", "hep-th/9901001"); !ok || got != "summary" {
+		t.Fatalf("expected a hit, got %q, %v", got, ok)
+	}
+	if _, ok := c.Get("citations", "hep-th/9901001"); ok {
+		t.Fatalf("kinds should not share entries")
+	}
+
+	now = now.Add(25 * time.Hour)
+	if _, ok := c.Get("kimi", "hep-th/9901001"); ok {
+		t.Fatalf("expected an expired entry to miss")
+	}
+
+	var nilCache *Cache
+	if err := nilCache.Put("kimi", "x", "y"); err != nil {
+		t.Fatalf("nil cache put: %v", err)
+	}
+	if _, ok := nilCache.Get("kimi", "x"); ok {
+		t.Fatalf("nil cache should always miss")
+	}
+}
+
+func TestStatsAndPrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	c := Open(t.TempDir(), Options{TTL: 10 * 24 * time.Hour, MaxBytes: 600})
+	c.now = func() time.Time { return now }
+
+	value := strings.Repeat("x", 100)
+	put := func(kind, id string, age time.Duration) {
+		c.now = func() time.Time { return now.Add(-age) }
+		if err := c.Put(kind, id, value); err != nil {
+			t.Fatalf("put %s: %v", id, err)
+		}
+		c.now = func() time.Time { return now }
+	}
+	put("kimi", "2602.00001", 11*24*time.Hour) // expired
+	put("kimi", "2602.00002", 3*time.Hour)     // oldest fresh entry
+	put("kimi", "2602.00003", 2*time.Hour)
+	put("kimi", "2602.00004", time.Hour)
+	put("citations", "2602.00001", 0)
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Kind != "citations" || stats[1].Entries != 4 || stats[1].Expired != 1 {
+		t.Fatalf("unexpected stats %#v", stats)
+	}
+
+	// Each entry is ~170 bytes, so 4 fresh entries exceed 600 bytes by one.
+	result, err := c.Prune()
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if result.Expired != 1 || result.Evicted != 1 || result.Bytes == 0 {
+		t.Fatalf("unexpected prune result %#v", result)
+	}
+	if _, ok := c.Get("kimi", "2602.00002"); ok {
+		t.Fatalf("the oldest entry should have been evicted")
+	}
+	if _, ok := c.Get("kimi", "2602.00004"); !ok {
+		t.Fatalf("the newest entry should be kept")
+	}
+}
+
+func TestStatsOnMissingDir(t *testing.T) {
+	t.Parallel()
+
+	c := Open(t.TempDir()+"/missing", Options{})
+	stats, err := c.Stats()
+	if err != nil || len(stats) != 0 {
+		t.Fatalf("expected empty stats for a missing cache, got %#v, %v", stats, err)
+	}
+}
@@ -0,0 +1,196 @@
+// Package citations looks papers up in a Semantic Scholar-compatible API
+// (the Graph API's paper batch endpoint) for their citation counts, venue
+// and open-access PDF.
+package citations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/cache"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+// DefaultBaseURL is the Semantic Scholar API.
+const DefaultBaseURL = "https://api.semanticscholar.org"
+
+const (
+	// batchSize is how many papers one batch request asks for; the API
+	// takes up to 500.
+	batchSize = 100
+
+	// cacheKind is the enrichment cache kind of citation data.
+	cacheKind = "citations"
+
+	// maxAge is how long cached citation data is used. Counts of new
+	// papers move quickly, so this is shorter than the cache's TTL.
+	maxAge = 7 * 24 * time.Hour
+
+	fields = "citationCount,influentialCitationCount,venue,openAccessPdf"
+)
+
+type Client struct {
+	httpClient *httpclient.Client
+	baseURL    string
+	apiKey     string
+	cache      *cache.Cache
+	now        func() time.Time
+}
+
+// NewClient returns a client for the API at baseURL (DefaultBaseURL when
+// empty). apiKey, when set, is sent as x-api-key.
+func NewClient(baseURL, apiKey string, policy httpclient.Policy) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		httpClient: httpclient.New("citations", policy),
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		now:        time.Now,
+	}
+}
+
+// UseCache makes Lookup read through enrichments, keyed by canonical paper
+// ID.
+func (c *Client) UseCache(enrichments *cache.Cache) {
+	c.cache = enrichments
+}
+
+// Supported reports whether a paper with canonical ID id can be looked up:
+// arXiv papers, and papers identified by DOI (the feed source's "doi:"
+// IDs).
+func Supported(id string) bool {
+	_, ok := paperRef(id)
+	return ok
+}
+
+func paperRef(id string) (string, bool) {
+	if doi, ok := strings.CutPrefix(id, model.DOIPrefix); ok && doi != "" {
+		return "DOI:" + doi, true
+	}
+	if arxivID, ok := model.ParseArxivID(id); ok && arxivID.Base == id {
+		return "arXiv:" + id, true
+	}
+	return "", false
+}
+
+// cached is the cache entry of one paper.
+type cached struct {
+	Citations model.Citations `json:"citations"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// Lookup returns the citation data of the papers with canonical IDs ids
+// that the API knows. Unsupported IDs are skipped.
+func (c *Client) Lookup(ctx context.Context, ids []string) (map[string]model.Citations, error) {
+	found := make(map[string]model.Citations)
+	var missing []string
+	for _, id := range ids {
+		if !Supported(id) {
+			continue
+		}
+		if value, ok := c.cache.Get(cacheKind, id); ok {
+			var entry cached
+			if json.Unmarshal([]byte(value), &entry) == nil && c.now().Sub(entry.FetchedAt) < maxAge {
+				found[id] = entry.Citations
+				continue
+			}
+		}
+		missing = append(missing, id)
+	}
+
+	for start := 0; start < len(missing); start += batchSize {
+		batch := missing[start:min(start+batchSize, len(missing))]
+		results, err := c.batch(ctx, batch)
+		if err != nil {
+			return found, err
+		}
+		for id, citations := range results {
+			found[id] = citations
+			if data, err := json.Marshal(cached{Citations: citations, FetchedAt: c.now().UTC()}); err == nil {
+				// A cache that cannot be written only costs a lookup next time.
+				_ = c.cache.Put(cacheKind, id, string(data))
+			}
+		}
+	}
+	return found, nil
+}
+
+// apiPaper is one entry of a batch response; unknown papers are null.
+type apiPaper struct {
+	CitationCount            int    `json:"citationCount"`
+	InfluentialCitationCount int    `json:"influentialCitationCount"`
+	Venue                    string `json:"venue"`
+	OpenAccessPDF            *struct {
+		URL string `json:"url"`
+	} `json:"openAccessPdf"`
+}
+
+func (c *Client) batch(ctx context.Context, ids []string) (map[string]model.Citations, error) {
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i], _ = paperRef(id)
+	}
+	body, err := json.Marshal(map[string][]string{"ids": refs})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := c.baseURL + "/graph/v1/paper/batch?fields=" + fields
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "paper-radar/0.2.0")
+	req.Header.Set("Content-Type", "application/json")
+	// A batch lookup only reads, so it may be retried like a GET; the nil
+	// key opts in without sending the header.
+	req.Header["Idempotency-Key"] = nil
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var papers []*apiPaper
+	if err := json.NewDecoder(resp.Body).Decode(&papers); err != nil {
+		return nil, err
+	}
+	if len(papers) != len(ids) {
+		return nil, fmt.Errorf("asked for %d papers, got %d", len(ids), len(papers))
+	}
+
+	results := make(map[string]model.Citations)
+	for i, paper := range papers {
+		if paper == nil {
+			continue
+		}
+		citations := model.Citations{
+			Count:       paper.CitationCount,
+			Influential: paper.InfluentialCitationCount,
+			Venue:       strings.TrimSpace(paper.Venue),
+		}
+		if paper.OpenAccessPDF != nil {
+			citations.OpenAccessPDF = strings.TrimSpace(paper.OpenAccessPDF.URL)
+		}
+		results[ids[i]] = citations
+	}
+	return results, nil
+}
@@ -0,0 +1,110 @@
+package citations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/cache"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+)
+
+func stubServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	known := map[string]string{
+		"arXiv:2602.00001":     `{"paperId": "a", "citationCount": 42, "influentialCitationCount": 5, "venue": "CVPR", "openAccessPdf": {"url": "https://example.org/a.pdf", "status": "GREEN"}}`,
+		"DOI:10.1101/2026.1.2": `{"paperId": "b", "citationCount": 3, "influentialCitationCount": 0, "venue": "", "openAccessPdf": null}`,
+	}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method != http.MethodPost || r.URL.Path != "/graph/v1/paper/batch" || r.URL.Query().Get("fields") != fields {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if r.Header.Get("x-api-key") != "secret" {
+			t.Errorf("expected the API key header, got %q", r.Header.Get("x-api-key"))
+		}
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.Write([]byte("["))
+		for i, id := range body.IDs {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			if paper, ok := known[id]; ok {
+				w.Write([]byte(paper))
+			} else {
+				w.Write([]byte("null"))
+			}
+		}
+		w.Write([]byte("]"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestLookupBatchesAndReadsThroughCache(t *testing.T) {
+	t.Parallel()
+
+	server, calls := stubServer(t)
+	client := NewClient(server.URL+"/", "secret", httpclient.Policy{Attempts: 1})
+	client.UseCache(cache.Open(t.TempDir(), cache.Options{}))
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	client.now = func() time.Time { return now }
+
+	ids := []string{"2602.00001", "doi:10.1101/2026.1.2", "2602.00404", "https://lab.example/post"}
+	found, err := client.Lookup(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if len(found) != 2 || calls.Load() != 1 {
+		t.Fatalf("expected 2 papers from one request, got %#v after %d calls", found, calls.Load())
+	}
+	if got := found["2602.00001"]; got.Count != 42 || got.Influential != 5 || got.Venue != "CVPR" || got.OpenAccessPDF != "https://example.org/a.pdf" {
+		t.Fatalf("unexpected citations %#v", got)
+	}
+	if got := found["doi:10.1101/2026.1.2"]; got.Count != 3 || got.OpenAccessPDF != "" {
+		t.Fatalf("unexpected DOI citations %#v", got)
+	}
+
+	// Found papers are served from the cache; the unknown one is asked
+	// for again.
+	found, err = client.Lookup(context.Background(), ids[:2])
+	if err != nil || len(found) != 2 || calls.Load() != 1 {
+		t.Fatalf("expected cached results without a request, got %#v, %v after %d calls", found, err, calls.Load())
+	}
+	if _, err := client.Lookup(context.Background(), ids[2:3]); err != nil || calls.Load() != 2 {
+		t.Fatalf("expected the unknown paper to be asked for again, got %v after %d calls", err, calls.Load())
+	}
+
+	// Counts go stale after maxAge.
+	now = now.Add(maxAge + time.Hour)
+	if _, err := client.Lookup(context.Background(), ids[:1]); err != nil || calls.Load() != 3 {
+		t.Fatalf("expected stale counts to be looked up again, got %v after %d calls", err, calls.Load())
+	}
+}
+
+func TestSupported(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"2602.00001":            true,
+		"hep-th/9901001":        true,
+		"doi:10.1000/xyz":       true,
+		"doi:":                  false,
+		"2602.00001v2":          false, // not canonical
+		"https://lab.example/p": false,
+	}
+	for id, want := range cases {
+		if got := Supported(id); got != want {
+			t.Fatalf("Supported(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -1,37 +1,299 @@
 package config
 
 import (
-	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/cache"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/query"
+	"github.com/kyc001/paper-radar/internal/source"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	MaxResults    int
-	MinScore      int
-	FeishuWebhook string
-	Topics        []Topic
+	MaxResults     int     `yaml:"max_results"`
+	MaxAgeDays     int     `yaml:"max_age_days"`
+	MinScore       float64 `yaml:"min_score"`
+	TitleWeight    int     `yaml:"title_weight"`
+	AbstractWeight int     `yaml:"abstract_weight"`
+	MaxKeywordHits int     `yaml:"max_keyword_hits"`
+	Concurrency    int     `yaml:"concurrency"`
+	FeishuWebhook  string  `yaml:"feishu_webhook"`
+	Topics         []Topic `yaml:"topics"`
+
+	// Followed authors and affiliations (labs) apply to every topic;
+	// topics may add their own and override the mode and bonus.
+	FollowAuthors      []FollowAuthor `yaml:"follow_authors"`
+	FollowAffiliations []string       `yaml:"follow_affiliations"`
+	FollowMode         string         `yaml:"follow_mode"`
+	FollowBonus        int            `yaml:"follow_bonus"`
+
+	// HTTP tunes retries per source, keyed by source name, "citations" or
+	// "feishu". Names are checked by the SourceChecker given to Load.
+	HTTP map[string]HTTPSettings `yaml:"http"`
+
+	Cache CacheSettings `yaml:"cache"`
+
+	// Citations looks queued papers up for citation data; CitationRules
+	// apply to every topic, after the topic's own.
+	Citations     CitationSettings `yaml:"citations"`
+	CitationRules []CitationRule   `yaml:"citation_rules"`
+}
+
+// CitationSettings configure the citation lookup of queued papers.
+type CitationSettings struct {
+	Enabled bool `yaml:"enabled"`
+	// BaseURL is the Semantic Scholar-compatible API to ask; empty means
+	// Semantic Scholar itself.
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"`
+}
+
+// CitationRule adds Bonus points (negative to demote) to a queued paper
+// whose citation data matches: a count field at least Min, a venue
+// containing Match (case-insensitively), or an open-access PDF.
+type CitationRule struct {
+	Field string `yaml:"field"`
+	Min   int    `yaml:"min"`
+	Match string `yaml:"match"`
+	Bonus int    `yaml:"bonus"`
+}
+
+// CacheSettings bounds the enrichment cache in the state directory. Zero
+// values keep the cache's defaults.
+type CacheSettings struct {
+	// TTL is how long a cached enrichment is served, e.g. "720h".
+	TTL time.Duration `yaml:"ttl"`
+	// MaxSizeMB is the size the cache is pruned back to.
+	MaxSizeMB int `yaml:"max_size_mb"`
+}
+
+// HTTPSettings tunes the shared HTTP client for one source. Zero values
+// keep the client's defaults.
+type HTTPSettings struct {
+	// Attempts is the total number of tries per request, including the first.
+	Attempts int `yaml:"attempts"`
+	// Timeout bounds each attempt, e.g. "30s".
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxPerHost bounds concurrent requests to one host of the source.
+	MaxPerHost int `yaml:"max_per_host"`
 }
 
 type Topic struct {
-	Name        string
-	Source      string
-	Query       string
-	Keywords    []string
-	MaxResults  int
-	MinScore    int
-	KimiSummary bool
+	Name   string `yaml:"name"`
+	Source string `yaml:"source"`
+	// SourceOptions is passed to the source as is; each source defines
+	// and validates its own options.
+	SourceOptions   source.Options `yaml:"source_options"`
+	Query           string         `yaml:"query"`
+	Filter          Filter         `yaml:"filter"`
+	Keywords        []Keyword      `yaml:"keywords"`
+	ExcludeKeywords []Keyword      `yaml:"exclude_keywords"`
+	ExcludeMode     string         `yaml:"exclude_mode"`
+	Match           string         `yaml:"match"`
+	Scorer          string         `yaml:"scorer"`
+	SeedPapers      []string       `yaml:"seed_papers"`
+	SeedMode        string         `yaml:"seed_mode"`
+	SeedWeight      int            `yaml:"seed_weight"`
+	TitleWeight     int            `yaml:"title_weight"`
+	AbstractWeight  int            `yaml:"abstract_weight"`
+	MaxKeywordHits  int            `yaml:"max_keyword_hits"`
+	MaxResults      int            `yaml:"max_results"`
+	MaxAgeDays      int            `yaml:"max_age_days"`
+	MinScore        float64        `yaml:"min_score"`
+	KimiSummary     bool           `yaml:"kimi_summary"`
+	// NotifyRevisions re-queues papers seen in an earlier run when a new
+	// version appears and it still scores above min_score for this topic.
+	NotifyRevisions bool `yaml:"notify_revisions"`
+	// FollowAuthors and FollowAffiliations hold the global follow lists
+	// followed by the topic's own once the config is validated.
+	FollowAuthors      []FollowAuthor `yaml:"follow_authors"`
+	FollowAffiliations []string       `yaml:"follow_affiliations"`
+	FollowMode         string         `yaml:"follow_mode"`
+	FollowBonus        int            `yaml:"follow_bonus"`
+	// CitationRules holds the topic's rules followed by the global ones
+	// once the config is validated.
+	CitationRules []CitationRule `yaml:"citation_rules"`
+}
+
+// DefaultSource is the source of topics that do not name one.
+const DefaultSource = "arxiv"
+
+const (
+	// ExcludeVeto drops a paper from the topic as soon as any exclude keyword matches.
+	ExcludeVeto = "veto"
+	// ExcludePenalty subtracts hits × weight of each exclude keyword from the score.
+	ExcludePenalty = "penalty"
+)
+
+const (
+	// MatchSubstring counts raw case-insensitive substrings ("3d" hits "3dgs").
+	MatchSubstring = "substring"
+	// MatchToken counts whole tokens and token phrases.
+	MatchToken = "token"
+	// MatchStem is MatchToken with plural folding and light stemming.
+	MatchStem = "stem"
+)
+
+const (
+	// ScorerKeyword sums hits × weight × field weight per keyword.
+	ScorerKeyword = "keyword"
+	// ScorerBM25 ranks with Okapi BM25 over the run's corpus plus history.
+	ScorerBM25 = "bm25"
+	// ScorerTFIDF ranks with TF-IDF over the run's corpus plus history.
+	ScorerTFIDF = "tfidf"
+)
+
+const (
+	// SeedCombine adds seed similarity to the keyword score.
+	SeedCombine = "combine"
+	// SeedReplace scores by seed similarity alone; keywords are optional.
+	SeedReplace = "replace"
+
+	// DefaultSeedWeight scales cosine similarity (0..1) to keyword-score range.
+	DefaultSeedWeight = 10
+)
+
+const (
+	// FollowBonus adds follow_bonus points when a followed author or
+	// affiliation matches.
+	FollowBonus = "bonus"
+	// FollowBypass queues a paper by a followed author or affiliation
+	// whatever its score, skipping the topic's filter.
+	FollowBypass = "bypass"
+
+	// DefaultFollowBonus is the follow_bonus of bonus mode when unset.
+	DefaultFollowBonus = 10
+)
+
+// Citation rule fields.
+const (
+	CitationCount       = "citations"
+	CitationInfluential = "influential_citations"
+	CitationVenue       = "venue"
+	CitationOpenAccess  = "open_access_pdf"
+)
+
+// FollowAuthor is an author whose papers matter regardless of keywords. In
+// YAML it is either a bare name ("Kaiming He") or a map listing other
+// spellings the author publishes under ({name: "Kaiming He", variants:
+// ["K. He"]}).
+type FollowAuthor struct {
+	Name     string   `yaml:"name"`
+	Variants []string `yaml:"variants"`
+}
+
+var followAuthorFields = map[string]bool{"name": true, "variants": true, "<<": true}
+
+func (f *FollowAuthor) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return f.UnmarshalYAML(node.Alias)
+	case yaml.ScalarNode:
+		f.Name = node.Value
+		return nil
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; !followAuthorFields[key] {
+				return fmt.Errorf("line %d: unknown follow_authors field %q", node.Content[i].Line, key)
+			}
+		}
+	default:
+		return fmt.Errorf("line %d: followed author must be a name or {name, variants} map", node.Line)
+	}
+
+	type plain FollowAuthor
+	var decoded plain
+	if err := node.Decode(&decoded); err != nil {
+		return err
+	}
+	*f = FollowAuthor(decoded)
+	return nil
+}
+
+// Keyword is a scoring term. In YAML it is either a bare string
+// ("video") or a map with an explicit weight ({word: "3D", weight: 10}).
+// MaxHits caps how many matches of the word count toward the score,
+// overriding the topic's max_keyword_hits; 0 means no keyword-level cap.
+type Keyword struct {
+	Word    string `yaml:"word"`
+	Weight  int    `yaml:"weight"`
+	MaxHits int    `yaml:"max_hits"`
+}
+
+var keywordFields = map[string]bool{"word": true, "weight": true, "max_hits": true, "<<": true}
+
+func (k *Keyword) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return k.UnmarshalYAML(node.Alias)
+	case yaml.ScalarNode:
+		k.Word = node.Value
+		return nil
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; !keywordFields[key] {
+				return fmt.Errorf("line %d: unknown keyword field %q", node.Content[i].Line, key)
+			}
+		}
+	default:
+		return fmt.Errorf("line %d: keyword must be a string or {word, weight} map", node.Line)
+	}
+
+	type plain Keyword
+	var decoded plain
+	if err := node.Decode(&decoded); err != nil {
+		return err
+	}
+	*k = Keyword(decoded)
+	return nil
+}
+
+// Filter is a boolean keyword expression (see package query) that a paper
+// must satisfy before the topic's keywords are scored. Source is parsed into
+// Expr by Config.Validate; an empty Source leaves Expr nil and gates nothing.
+type Filter struct {
+	Source string
+	Expr   query.Expr
+	line   int
+}
+
+func (f *Filter) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		return f.UnmarshalYAML(node.Alias)
+	}
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: filter must be a string", node.Line)
+	}
+	f.Source = node.Value
+	f.line = node.Line
+	return nil
+}
+
+// SourceChecker checks what depends on the sources built into the
+// program, which this package does not know: a topic's source name, query
+// and source_options, and the keys of http.
+type SourceChecker interface {
+	CheckTopic(topic Topic) error
+	CheckHTTPName(name string) error
 }
 
-func Load(path string) (Config, error) {
+// Load reads, parses and validates the config at path, checking its
+// sources with sources unless that is nil.
+func Load(path string, sources SourceChecker) (Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return Config{}, err
 	}
 
-	cfg, err := parseYAMLSubset(string(data))
+	cfg, err := Parse(data)
 	if err != nil {
 		return Config{}, err
 	}
@@ -39,6 +301,46 @@ func Load(path string) (Config, error) {
 	if err := (&cfg).Validate(); err != nil {
 		return Config{}, err
 	}
+	if sources != nil {
+		if err := cfg.CheckSources(sources); err != nil {
+			return Config{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// CheckSources checks a validated config's http keys and topic sources
+// with sources.
+func (c Config) CheckSources(sources SourceChecker) error {
+	names := make([]string, 0, len(c.HTTP))
+	for name := range c.HTTP {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := sources.CheckHTTPName(name); err != nil {
+			return fmt.Errorf("http: %w", err)
+		}
+	}
+	for i, topic := range c.Topics {
+		if err := sources.CheckTopic(topic); err != nil {
+			return fmt.Errorf("topic[%d] (%s) %w", i, topic.Name, err)
+		}
+	}
+	return nil
+}
+
+// Parse decodes YAML config content. Unknown keys are rejected so typos
+// surface instead of being silently ignored.
+func Parse(data []byte) (Config, error) {
+	var cfg Config
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
 
 	return cfg, nil
 }
@@ -51,55 +353,148 @@ func (c *Config) Validate() error {
 	if c.MinScore < 0 {
 		return fmt.Errorf("min_score must be >= 0")
 	}
+	if c.TitleWeight < 0 || c.AbstractWeight < 0 {
+		return fmt.Errorf("title_weight and abstract_weight must be >= 0")
+	}
+	if c.MaxKeywordHits < 0 {
+		return fmt.Errorf("max_keyword_hits must be >= 0")
+	}
+	if c.MaxAgeDays < 0 {
+		return fmt.Errorf("max_age_days must be >= 0")
+	}
+	if c.Concurrency < 0 {
+		return fmt.Errorf("concurrency must be >= 0")
+	}
+	if c.Cache.TTL < 0 || c.Cache.MaxSizeMB < 0 {
+		return fmt.Errorf("cache ttl and max_size_mb must be >= 0")
+	}
 
 	c.FeishuWebhook = strings.TrimSpace(c.FeishuWebhook)
 
+	follows, err := normalizeFollows(c.FollowAuthors)
+	if err != nil {
+		return err
+	}
+	c.FollowAuthors = follows
+	c.FollowAffiliations = mergeNames(c.FollowAffiliations, nil)
+	c.FollowMode = strings.ToLower(strings.TrimSpace(c.FollowMode))
+	if c.FollowMode != "" && c.FollowMode != FollowBonus && c.FollowMode != FollowBypass {
+		return fmt.Errorf("follow_mode must be bonus or bypass")
+	}
+	if c.FollowBonus < 0 {
+		return fmt.Errorf("follow_bonus must be >= 0")
+	}
+
+	c.Citations.BaseURL = strings.TrimSpace(c.Citations.BaseURL)
+	rules, err := normalizeCitationRules(c.CitationRules)
+	if err != nil {
+		return err
+	}
+	c.CitationRules = rules
+	if len(c.CitationRules) > 0 && !c.Citations.Enabled {
+		return fmt.Errorf("citation_rules need citations.enabled")
+	}
+
+	for name, settings := range c.HTTP {
+		if settings.Attempts < 0 || settings.Timeout < 0 || settings.MaxPerHost < 0 {
+			return fmt.Errorf("http.%s attempts, timeout and max_per_host must be >= 0", name)
+		}
+	}
+
 	for i, topic := range c.Topics {
 		topic.Name = strings.TrimSpace(topic.Name)
 		if topic.Name == "" {
 			return fmt.Errorf("topic[%d] must have a name", i)
 		}
 
+		// The source name and its options are checked by CheckSources.
 		topic.Source = strings.ToLower(strings.TrimSpace(topic.Source))
 		if topic.Source == "" {
-			topic.Source = "arxiv"
-		}
-		if topic.Source != "arxiv" && topic.Source != "paperscool" {
-			return fmt.Errorf("topic[%d] (%s) source must be arxiv or paperscool", i, topic.Name)
+			topic.Source = DefaultSource
 		}
 
-		topic.Keywords = normalizeKeywords(topic.Keywords)
+		keywords, err := normalizeKeywords(topic.Keywords)
+		if err != nil {
+			return fmt.Errorf("topic[%d] (%s) %w", i, topic.Name, err)
+		}
+		topic.Keywords = keywords
 		topic.Query = strings.TrimSpace(topic.Query)
 
-		if len(topic.Keywords) == 0 {
+		if err := normalizeSeeds(&topic); err != nil {
+			return fmt.Errorf("topic[%d] (%s) %w", i, topic.Name, err)
+		}
+		if err := c.inheritFollows(&topic); err != nil {
+			return fmt.Errorf("topic[%d] (%s) %w", i, topic.Name, err)
+		}
+		rules, err := normalizeCitationRules(topic.CitationRules)
+		if err != nil {
+			return fmt.Errorf("topic[%d] (%s) %w", i, topic.Name, err)
+		}
+		if len(rules) > 0 && !c.Citations.Enabled {
+			return fmt.Errorf("topic[%d] (%s) citation_rules need citations.enabled", i, topic.Name)
+		}
+		topic.CitationRules = append(rules, c.CitationRules...)
+
+		if len(topic.Keywords) == 0 && topic.SeedMode != SeedReplace {
 			return fmt.Errorf("topic[%d] (%s) must have at least one keyword", i, topic.Name)
 		}
-		if topic.MinScore < 0 {
-			return fmt.Errorf("topic[%d] (%s) min_score must be >= 0", i, topic.Name)
+
+		topic.Match = strings.ToLower(strings.TrimSpace(topic.Match))
+		if topic.Match == "" {
+			topic.Match = MatchSubstring
+		}
+		if topic.Match != MatchSubstring && topic.Match != MatchToken && topic.Match != MatchStem {
+			return fmt.Errorf("topic[%d] (%s) match must be substring, token or stem", i, topic.Name)
 		}
 
-		c.Topics[i] = topic
-	}
+		if topic.TitleWeight < 0 || topic.AbstractWeight < 0 {
+			return fmt.Errorf("topic[%d] (%s) title_weight and abstract_weight must be >= 0", i, topic.Name)
+		}
+		if topic.MaxKeywordHits < 0 {
+			return fmt.Errorf("topic[%d] (%s) max_keyword_hits must be >= 0", i, topic.Name)
+		}
+		// Topics inherit the global field weights and hit cap; scoring only
+		// looks at the topic.
+		topic.TitleWeight = firstPositive(topic.TitleWeight, c.TitleWeight, 1)
+		topic.AbstractWeight = firstPositive(topic.AbstractWeight, c.AbstractWeight, 1)
+		topic.MaxKeywordHits = firstPositive(topic.MaxKeywordHits, c.MaxKeywordHits)
+
+		topic.Scorer = strings.ToLower(strings.TrimSpace(topic.Scorer))
+		if topic.Scorer == "" {
+			topic.Scorer = ScorerKeyword
+		}
+		if topic.Scorer != ScorerKeyword && topic.Scorer != ScorerBM25 && topic.Scorer != ScorerTFIDF {
+			return fmt.Errorf("topic[%d] (%s) scorer must be keyword, bm25 or tfidf", i, topic.Name)
+		}
 
-	return nil
-}
+		if err := topic.Filter.parse(); err != nil {
+			return fmt.Errorf("topic[%d] (%s) %w", i, topic.Name, err)
+		}
 
-func (c Config) TopicQuery(topic Topic) string {
-	if topic.Query != "" {
-		return topic.Query
-	}
+		excludes, err := normalizeKeywords(topic.ExcludeKeywords)
+		if err != nil {
+			return fmt.Errorf("topic[%d] (%s) exclude_keywords: %w", i, topic.Name, err)
+		}
+		topic.ExcludeKeywords = excludes
 
-	if topic.Source == "paperscool" {
-		// default papers.cool category when query is omitted
-		return "cs.AI"
-	}
+		topic.ExcludeMode = strings.ToLower(strings.TrimSpace(topic.ExcludeMode))
+		if topic.ExcludeMode == "" {
+			topic.ExcludeMode = ExcludeVeto
+		}
+		if topic.ExcludeMode != ExcludeVeto && topic.ExcludeMode != ExcludePenalty {
+			return fmt.Errorf("topic[%d] (%s) exclude_mode must be veto or penalty", i, topic.Name)
+		}
+		if topic.MinScore < 0 {
+			return fmt.Errorf("topic[%d] (%s) min_score must be >= 0", i, topic.Name)
+		}
+		if topic.MaxAgeDays < 0 {
+			return fmt.Errorf("topic[%d] (%s) max_age_days must be >= 0", i, topic.Name)
+		}
 
-	parts := make([]string, 0, len(topic.Keywords))
-	for _, keyword := range topic.Keywords {
-		parts = append(parts, fmt.Sprintf("all:\"%s\"", keyword))
+		c.Topics[i] = topic
 	}
 
-	return strings.Join(parts, " OR ")
+	return nil
 }
 
 func (c Config) EffectiveMaxResults(topic Topic, override int) int {
@@ -115,7 +510,37 @@ func (c Config) EffectiveMaxResults(topic Topic, override int) int {
 	return 25
 }
 
-func (c Config) EffectiveMinScore(topic Topic, override int) int {
+// DefaultConcurrency is how many topics are fetched at once when
+// concurrency is unset.
+const DefaultConcurrency = 4
+
+// EffectiveConcurrency returns how many topics to fetch at once.
+func (c Config) EffectiveConcurrency() int {
+	return firstPositive(c.Concurrency, DefaultConcurrency)
+}
+
+// CacheOptions returns the enrichment cache limits.
+func (c Config) CacheOptions() cache.Options {
+	return cache.Options{TTL: c.Cache.TTL, MaxBytes: int64(c.Cache.MaxSizeMB) << 20}
+}
+
+// HTTPPolicy returns the retry policy configured for the named source.
+func (c Config) HTTPPolicy(name string) httpclient.Policy {
+	settings := c.HTTP[name]
+	return httpclient.Policy{Attempts: settings.Attempts, Timeout: settings.Timeout, MaxPerHost: settings.MaxPerHost}
+}
+
+// EffectiveSince returns the submission date before which arXiv paging
+// stops for topic, or the zero time when no max_age_days applies.
+func (c Config) EffectiveSince(topic Topic, now time.Time) time.Time {
+	days := firstPositive(topic.MaxAgeDays, c.MaxAgeDays)
+	if days == 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -days)
+}
+
+func (c Config) EffectiveMinScore(topic Topic, override float64) float64 {
 	if override > 0 {
 		return override
 	}
@@ -128,206 +553,188 @@ func (c Config) EffectiveMinScore(topic Topic, override int) int {
 	return 1
 }
 
-func normalizeKeywords(keywords []string) []string {
-	normalized := make([]string, 0, len(keywords))
-	for _, keyword := range keywords {
-		keyword = strings.TrimSpace(keyword)
-		if keyword == "" {
-			continue
+// normalizeSeeds trims seed entries and resolves seed mode and weight.
+func normalizeSeeds(topic *Topic) error {
+	seeds := make([]string, 0, len(topic.SeedPapers))
+	for _, seed := range topic.SeedPapers {
+		if seed = strings.TrimSpace(seed); seed != "" {
+			seeds = append(seeds, seed)
 		}
-		normalized = append(normalized, keyword)
 	}
-	return normalized
-}
-
-func parseYAMLSubset(content string) (Config, error) {
-	var cfg Config
-	var current *Topic
-	inTopics := false
-	inKeywords := false
-
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	lineNo := 0
+	topic.SeedPapers = seeds
 
-	flushTopic := func() {
-		if current != nil {
-			cfg.Topics = append(cfg.Topics, *current)
-			current = nil
-		}
+	topic.SeedMode = strings.ToLower(strings.TrimSpace(topic.SeedMode))
+	if topic.SeedMode == "" {
+		topic.SeedMode = SeedCombine
 	}
+	if topic.SeedMode != SeedCombine && topic.SeedMode != SeedReplace {
+		return fmt.Errorf("seed_mode must be combine or replace")
+	}
+	if topic.SeedMode == SeedReplace && len(topic.SeedPapers) == 0 {
+		return fmt.Errorf("seed_mode replace requires seed_papers")
+	}
+	if topic.SeedWeight < 0 {
+		return fmt.Errorf("seed_weight must be >= 0")
+	}
+	if topic.SeedWeight == 0 {
+		topic.SeedWeight = DefaultSeedWeight
+	}
+	return nil
+}
 
-	for scanner.Scan() {
-		lineNo++
-		raw := strings.TrimRight(scanner.Text(), " \t")
-		line := strings.TrimSpace(stripInlineComment(raw))
-		if line == "" {
-			continue
-		}
-
-		indent := len(raw) - len(strings.TrimLeft(raw, " "))
-
-		if strings.HasPrefix(line, "- ") {
-			item := strings.TrimSpace(strings.TrimPrefix(line, "- "))
-			if inKeywords && !strings.HasPrefix(item, "name:") {
-				if current == nil {
-					return Config{}, fmt.Errorf("line %d: keyword item appears before a topic", lineNo)
-				}
-				current.Keywords = append(current.Keywords, parseScalar(item))
-				continue
-			}
-
-			if !inTopics {
-				return Config{}, fmt.Errorf("line %d: list item appears outside topics", lineNo)
-			}
-
-			flushTopic()
-			current = &Topic{}
-
-			if strings.HasPrefix(item, "name:") {
-				current.Name = parseScalar(strings.TrimSpace(strings.TrimPrefix(item, "name:")))
-			} else {
-				return Config{}, fmt.Errorf("line %d: topic item must start with '- name:'", lineNo)
+// inheritFollows prepends the global follow lists to the topic's (a topic
+// entry with the same name as a global one is dropped) and fills in the
+// follow mode and bonus.
+func (c *Config) inheritFollows(topic *Topic) error {
+	follows, err := normalizeFollows(topic.FollowAuthors)
+	if err != nil {
+		return err
+	}
+	merged := append([]FollowAuthor(nil), c.FollowAuthors...)
+	for _, follow := range follows {
+		duplicate := false
+		for _, existing := range merged {
+			if strings.EqualFold(existing.Name, follow.Name) {
+				duplicate = true
+				break
 			}
-
-			inKeywords = false
-			continue
 		}
-
-		if strings.HasPrefix(line, "topics:") {
-			inTopics = true
-			inKeywords = false
-			continue
+		if !duplicate {
+			merged = append(merged, follow)
 		}
+	}
+	topic.FollowAuthors = merged
+	topic.FollowAffiliations = mergeNames(c.FollowAffiliations, topic.FollowAffiliations)
 
-		if strings.HasPrefix(line, "max_results:") {
-			value := strings.TrimSpace(strings.TrimPrefix(line, "max_results:"))
-			n, err := strconv.Atoi(value)
-			if err != nil {
-				return Config{}, fmt.Errorf("line %d: invalid max_results %q", lineNo, value)
-			}
-			if current != nil && indent > 0 {
-				current.MaxResults = n
-			} else {
-				cfg.MaxResults = n
-			}
-			inKeywords = false
-			continue
-		}
+	topic.FollowMode = strings.ToLower(strings.TrimSpace(topic.FollowMode))
+	if topic.FollowMode == "" {
+		topic.FollowMode = c.FollowMode
+	}
+	if topic.FollowMode == "" {
+		topic.FollowMode = FollowBonus
+	}
+	if topic.FollowMode != FollowBonus && topic.FollowMode != FollowBypass {
+		return fmt.Errorf("follow_mode must be bonus or bypass")
+	}
+	if topic.FollowBonus < 0 {
+		return fmt.Errorf("follow_bonus must be >= 0")
+	}
+	topic.FollowBonus = firstPositive(topic.FollowBonus, c.FollowBonus, DefaultFollowBonus)
+	return nil
+}
 
-		if strings.HasPrefix(line, "min_score:") {
-			value := strings.TrimSpace(strings.TrimPrefix(line, "min_score:"))
-			n, err := strconv.Atoi(value)
-			if err != nil {
-				return Config{}, fmt.Errorf("line %d: invalid min_score %q", lineNo, value)
+func normalizeCitationRules(rules []CitationRule) ([]CitationRule, error) {
+	normalized := make([]CitationRule, 0, len(rules))
+	for _, rule := range rules {
+		rule.Field = strings.ToLower(strings.TrimSpace(rule.Field))
+		rule.Match = strings.TrimSpace(rule.Match)
+		switch rule.Field {
+		case CitationCount, CitationInfluential:
+			if rule.Min <= 0 {
+				return nil, fmt.Errorf("citation_rules %s needs min > 0", rule.Field)
 			}
-			if current != nil && indent > 0 {
-				current.MinScore = n
-			} else {
-				cfg.MinScore = n
+		case CitationVenue:
+			if rule.Match == "" {
+				return nil, fmt.Errorf("citation_rules venue needs match")
 			}
-			inKeywords = false
-			continue
+		case CitationOpenAccess:
+		default:
+			return nil, fmt.Errorf("citation_rules field must be citations, influential_citations, venue or open_access_pdf, got %q", rule.Field)
 		}
-
-		if strings.HasPrefix(line, "feishu_webhook:") {
-			if current != nil && indent > 0 {
-				return Config{}, fmt.Errorf("line %d: feishu_webhook must be declared at top level", lineNo)
-			}
-			cfg.FeishuWebhook = parseScalar(strings.TrimSpace(strings.TrimPrefix(line, "feishu_webhook:")))
-			inKeywords = false
-			continue
+		if rule.Bonus == 0 {
+			return nil, fmt.Errorf("citation_rules %s needs a non-zero bonus", rule.Field)
 		}
+		normalized = append(normalized, rule)
+	}
+	return normalized, nil
+}
 
-		if strings.HasPrefix(line, "name:") {
-			if current == nil {
-				return Config{}, fmt.Errorf("line %d: name appears outside a topic", lineNo)
-			}
-			current.Name = parseScalar(strings.TrimSpace(strings.TrimPrefix(line, "name:")))
-			inKeywords = false
+// mergeNames returns the non-empty names of global then local, trimmed and
+// without case-insensitive duplicates.
+func mergeNames(global, local []string) []string {
+	var merged []string
+	for _, name := range append(append([]string(nil), global...), local...) {
+		name = strings.TrimSpace(name)
+		if name == "" {
 			continue
 		}
-
-		if strings.HasPrefix(line, "source:") {
-			if current == nil {
-				return Config{}, fmt.Errorf("line %d: source appears outside a topic", lineNo)
+		duplicate := false
+		for _, existing := range merged {
+			if strings.EqualFold(existing, name) {
+				duplicate = true
+				break
 			}
-			current.Source = parseScalar(strings.TrimSpace(strings.TrimPrefix(line, "source:")))
-			inKeywords = false
-			continue
 		}
-
-		if strings.HasPrefix(line, "query:") {
-			if current == nil {
-				return Config{}, fmt.Errorf("line %d: query appears outside a topic", lineNo)
-			}
-			current.Query = parseScalar(strings.TrimSpace(strings.TrimPrefix(line, "query:")))
-			inKeywords = false
-			continue
+		if !duplicate {
+			merged = append(merged, name)
 		}
+	}
+	return merged
+}
 
-		if strings.HasPrefix(line, "keywords:") {
-			if current == nil {
-				return Config{}, fmt.Errorf("line %d: keywords appears outside a topic", lineNo)
-			}
-			inKeywords = true
-			continue
+func normalizeFollows(follows []FollowAuthor) ([]FollowAuthor, error) {
+	normalized := make([]FollowAuthor, 0, len(follows))
+	for _, follow := range follows {
+		follow.Name = strings.TrimSpace(follow.Name)
+		if follow.Name == "" {
+			return nil, fmt.Errorf("follow_authors entries must have a name")
 		}
-
-		if strings.HasPrefix(line, "kimi_summary:") {
-			if current == nil {
-				return Config{}, fmt.Errorf("line %d: kimi_summary appears outside a topic", lineNo)
-			}
-			value := parseScalar(strings.TrimSpace(strings.TrimPrefix(line, "kimi_summary:")))
-			b, err := strconv.ParseBool(strings.ToLower(value))
-			if err != nil {
-				return Config{}, fmt.Errorf("line %d: invalid kimi_summary %q", lineNo, value)
+		variants := make([]string, 0, len(follow.Variants))
+		for _, variant := range follow.Variants {
+			if variant = strings.TrimSpace(variant); variant != "" {
+				variants = append(variants, variant)
 			}
-			current.KimiSummary = b
-			inKeywords = false
-			continue
 		}
-
-		return Config{}, fmt.Errorf("line %d: unsupported config line %q", lineNo, line)
+		follow.Variants = variants
+		normalized = append(normalized, follow)
 	}
+	return normalized, nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return Config{}, err
+func (f *Filter) parse() error {
+	f.Source = strings.TrimSpace(f.Source)
+	f.Expr = nil
+	if f.Source == "" {
+		return nil
 	}
 
-	flushTopic()
-	return cfg, nil
+	expr, err := query.Parse(f.Source)
+	if err != nil {
+		if f.line > 0 {
+			return fmt.Errorf("filter (config line %d): %w", f.line, err)
+		}
+		return fmt.Errorf("filter: %w", err)
+	}
+	f.Expr = expr
+	return nil
 }
 
-func parseScalar(value string) string {
-	value = strings.TrimSpace(value)
-	if len(value) >= 2 {
-		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
-			return value[1 : len(value)-1]
+func normalizeKeywords(keywords []Keyword) ([]Keyword, error) {
+	normalized := make([]Keyword, 0, len(keywords))
+	for _, keyword := range keywords {
+		keyword.Word = strings.TrimSpace(keyword.Word)
+		if keyword.Word == "" {
+			continue
+		}
+		if keyword.Weight < 0 {
+			return nil, fmt.Errorf("keyword %q weight must be >= 0", keyword.Word)
+		}
+		if keyword.MaxHits < 0 {
+			return nil, fmt.Errorf("keyword %q max_hits must be >= 0", keyword.Word)
+		}
+		if keyword.Weight == 0 {
+			keyword.Weight = 1
 		}
+		normalized = append(normalized, keyword)
 	}
-	return value
+	return normalized, nil
 }
 
-func stripInlineComment(line string) string {
-	inSingle := false
-	inDouble := false
-
-	for i, ch := range line {
-		switch ch {
-		case '\'':
-			if !inDouble {
-				inSingle = !inSingle
-			}
-		case '"':
-			if !inSingle {
-				inDouble = !inDouble
-			}
-		case '#':
-			if !inSingle && !inDouble {
-				return strings.TrimSpace(line[:i])
-			}
+func firstPositive(values ...int) int {
+	for _, value := range values {
+		if value > 0 {
+			return value
 		}
 	}
-
-	return line
+	return 0
 }
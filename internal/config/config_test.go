@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadParsesMinScoreAndMaxResults(t *testing.T) {
@@ -32,7 +35,7 @@ topics:
 		t.Fatalf("write config: %v", err)
 	}
 
-	cfg, err := Load(path)
+	cfg, err := Load(path, nil)
 	if err != nil {
 		t.Fatalf("load config: %v", err)
 	}
@@ -41,7 +44,7 @@ topics:
 		t.Fatalf("expected MaxResults=20, got %d", cfg.MaxResults)
 	}
 	if cfg.MinScore != 2 {
-		t.Fatalf("expected MinScore=2, got %d", cfg.MinScore)
+		t.Fatalf("expected MinScore=2, got %v", cfg.MinScore)
 	}
 	if cfg.FeishuWebhook == "" {
 		t.Fatalf("expected FeishuWebhook parsed")
@@ -50,7 +53,7 @@ topics:
 		t.Fatalf("expected 2 topics, got %d", len(cfg.Topics))
 	}
 	if cfg.Topics[0].MaxResults != 10 || cfg.Topics[0].MinScore != 4 {
-		t.Fatalf("topic A max/min parse mismatch: max=%d min=%d", cfg.Topics[0].MaxResults, cfg.Topics[0].MinScore)
+		t.Fatalf("topic A max/min parse mismatch: max=%v min=%v", cfg.Topics[0].MaxResults, cfg.Topics[0].MinScore)
 	}
 	if cfg.Topics[0].Source != "paperscool" {
 		t.Fatalf("expected topic source paperscool, got %q", cfg.Topics[0].Source)
@@ -63,6 +66,356 @@ topics:
 	}
 }
 
+func TestLoadParsesWeightedKeywordsAndAnchors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `topics:
+  - &base
+    name: "3D"
+    source: paperscool
+    min_score: 5
+    keywords:
+      - {word: "3D", weight: 10}
+      - word: "video generation"
+        weight: 8
+      - "diffusion"
+  - <<: *base
+    name: "3D (arXiv)"
+    source: arxiv
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	want := []Keyword{{Word: "3D", Weight: 10}, {Word: "video generation", Weight: 8}, {Word: "diffusion", Weight: 1}}
+	for i, topic := range cfg.Topics {
+		if len(topic.Keywords) != len(want) {
+			t.Fatalf("topic[%d] expected %d keywords, got %#v", i, len(want), topic.Keywords)
+		}
+		for j, keyword := range topic.Keywords {
+			if keyword != want[j] {
+				t.Fatalf("topic[%d] keyword[%d] = %#v, want %#v", i, j, keyword, want[j])
+			}
+		}
+	}
+	if cfg.Topics[1].Source != "arxiv" || cfg.Topics[1].MinScore != 5 {
+		t.Fatalf("merged topic mismatch: %#v", cfg.Topics[1])
+	}
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Parse([]byte("topics:\n  - name: A\n    keyword: [x]\n")); err == nil {
+		t.Fatalf("expected unknown topic key to be rejected")
+	}
+	if _, err := Parse([]byte("topics:\n  - name: A\n    keywords:\n      - {word: x, wieght: 2}\n")); err == nil {
+		t.Fatalf("expected unknown keyword field to be rejected")
+	}
+}
+
+func TestValidateParsesTopicFilter(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse([]byte(`topics:
+  - name: A
+    filter: "training-free AND (video OR 4d) AND NOT segmentation"
+    keywords: [video]
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+	if cfg.Topics[0].Filter.Expr == nil {
+		t.Fatalf("expected filter expression to be parsed")
+	}
+
+	cfg, err = Parse([]byte(`topics:
+  - name: A
+    filter: "video AND (4d OR"
+    keywords: [video]
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatalf("expected invalid filter to fail validation")
+	}
+	if !strings.Contains(err.Error(), "config line 3") || !strings.Contains(err.Error(), "line 1, column 17") {
+		t.Fatalf("error should carry positions, got %v", err)
+	}
+}
+
+func TestValidateInheritsFieldWeights(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse([]byte(`title_weight: 3
+max_keyword_hits: 5
+topics:
+  - name: A
+    keywords: [video]
+  - name: B
+    title_weight: 2
+    abstract_weight: 2
+    max_keyword_hits: 1
+    keywords: [{word: video, max_hits: 3}]
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+
+	a, b := cfg.Topics[0], cfg.Topics[1]
+	if a.TitleWeight != 3 || a.AbstractWeight != 1 || a.MaxKeywordHits != 5 {
+		t.Fatalf("topic A should inherit globals, got title=%d abstract=%d max=%d", a.TitleWeight, a.AbstractWeight, a.MaxKeywordHits)
+	}
+	if b.TitleWeight != 2 || b.AbstractWeight != 2 || b.MaxKeywordHits != 1 || b.Keywords[0].MaxHits != 3 {
+		t.Fatalf("topic B overrides mismatch: %#v", b)
+	}
+}
+
+func TestValidateSeedPapers(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{Topics: []Topic{{Name: "A", Query: "cat:cs.CV", SeedPapers: []string{"2602.23153"}, SeedMode: "replace"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("replace mode should not require keywords: %v", err)
+	}
+	if cfg.Topics[0].SeedWeight != DefaultSeedWeight {
+		t.Fatalf("expected default seed weight, got %d", cfg.Topics[0].SeedWeight)
+	}
+
+	cfg = Config{Topics: []Topic{{Name: "A", Query: "cat:cs.CV", SeedMode: "replace"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("replace mode without seeds should fail validation")
+	}
+}
+
+func TestValidateInheritsFollowAuthors(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse([]byte(`follow_authors:
+  - "Kaiming He"
+  - name: "Zoë Müller"
+    variants: ["Z. Muller"]
+follow_bonus: 6
+follow_affiliations: ["FAIR", " "]
+topics:
+  - name: A
+    keywords: [video]
+  - name: B
+    keywords: [video]
+    follow_mode: bypass
+    follow_affiliations: [fair, "Max Planck"]
+    follow_authors:
+      - "kaiming he"
+      - "Saining Xie"
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+
+	a, b := cfg.Topics[0], cfg.Topics[1]
+	if len(a.FollowAuthors) != 2 || a.FollowMode != FollowBonus || a.FollowBonus != 6 {
+		t.Fatalf("topic A should inherit the global follow list, got %#v", a)
+	}
+	if a.FollowAuthors[1].Name != "Zoë Müller" || len(a.FollowAuthors[1].Variants) != 1 {
+		t.Fatalf("expected mapping form with variants, got %#v", a.FollowAuthors[1])
+	}
+	if len(b.FollowAuthors) != 3 || b.FollowMode != FollowBypass {
+		t.Fatalf("topic B should merge the global list without duplicates, got %#v", b.FollowAuthors)
+	}
+	if len(a.FollowAffiliations) != 1 || len(b.FollowAffiliations) != 2 || b.FollowAffiliations[1] != "Max Planck" {
+		t.Fatalf("expected merged affiliations, got %q / %q", a.FollowAffiliations, b.FollowAffiliations)
+	}
+
+	cfg = Config{Topics: []Topic{{Name: "A", Query: "cat:cs.CV", Keywords: []Keyword{{Word: "video"}}, FollowMode: "always"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("unknown follow_mode should fail validation")
+	}
+}
+
+func TestValidateHTTPSettings(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse([]byte(`http:
+  arxiv: {attempts: 5, timeout: 45s}
+topics:
+  - name: A
+    keywords: [video]
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+	if got := cfg.HTTPPolicy("arxiv"); got.Attempts != 5 || got.Timeout != 45*time.Second {
+		t.Fatalf("unexpected arxiv policy %#v", got)
+	}
+	if got := cfg.HTTPPolicy("feishu"); got.Attempts != 0 || got.Timeout != 0 {
+		t.Fatalf("unconfigured sources should keep client defaults, got %#v", got)
+	}
+}
+
+func TestValidateCitationRules(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse([]byte(`citations:
+  enabled: true
+citation_rules:
+  - {field: open_access_pdf, bonus: 1}
+topics:
+  - name: A
+    keywords: [video]
+  - name: B
+    keywords: [video]
+    citation_rules:
+      - {field: " Citations ", min: 50, bonus: 3}
+      - {field: venue, match: CVPR, bonus: 2}
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+	a, b := cfg.Topics[0], cfg.Topics[1]
+	if len(a.CitationRules) != 1 || a.CitationRules[0].Field != CitationOpenAccess {
+		t.Fatalf("topic A should inherit the global rules, got %#v", a.CitationRules)
+	}
+	if len(b.CitationRules) != 3 || b.CitationRules[0].Field != CitationCount || b.CitationRules[2].Field != CitationOpenAccess {
+		t.Fatalf("topic B should list its own rules before the global ones, got %#v", b.CitationRules)
+	}
+
+	for _, rules := range []string{
+		`[{field: citations, bonus: 3}]`,
+		`[{field: venue, bonus: 3}]`,
+		`[{field: open_access_pdf}]`,
+		`[{field: stars, min: 1, bonus: 1}]`,
+	} {
+		cfg, err := Parse([]byte("citations: {enabled: true}\ncitation_rules: " + rules + "\ntopics:\n  - name: A\n    keywords: [video]\n"))
+		if err != nil {
+			t.Fatalf("parse config: %v", err)
+		}
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("expected %s to fail validation", rules)
+		}
+	}
+
+	cfg = Config{Topics: []Topic{{Name: "A", Keywords: []Keyword{{Word: "video"}}, CitationRules: []CitationRule{{Field: CitationOpenAccess, Bonus: 1}}}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "citations.enabled") {
+		t.Fatalf("citation rules without citations.enabled should fail, got %v", err)
+	}
+	cfg = Config{
+		CitationRules: []CitationRule{{Field: CitationOpenAccess, Bonus: 1}},
+		Topics:        []Topic{{Name: "A", Keywords: []Keyword{{Word: "video"}}}},
+	}
+	if err := cfg.Validate(); err == nil || strings.HasPrefix(err.Error(), "topic") || !strings.Contains(err.Error(), "citations.enabled") {
+		t.Fatalf("global citation rules without citations.enabled should fail at the top level, got %v", err)
+	}
+}
+
+func TestLoadBundledConfigs(t *testing.T) {
+	t.Parallel()
+
+	paths, err := filepath.Glob(filepath.Join("..", "..", "configs", "*.yaml"))
+	if err != nil {
+		t.Fatalf("glob configs: %v", err)
+	}
+	paths = append(paths, filepath.Join("..", "..", "config.example.yaml"))
+
+	for _, path := range paths {
+		if _, err := Load(path, nil); err != nil {
+			t.Fatalf("load %s: %v", path, err)
+		}
+	}
+}
+
+// knownSources is a SourceChecker for tests that knows two sources, one of
+// which takes no query.
+type knownSources struct{}
+
+func (knownSources) CheckTopic(topic Topic) error {
+	switch topic.Source {
+	case "arxiv":
+		return nil
+	case "needs-query":
+		if topic.Query == "" {
+			return fmt.Errorf("query must be set")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown source %q", topic.Source)
+}
+
+func (knownSources) CheckHTTPName(name string) error {
+	if name != "arxiv" && name != "feishu" {
+		return fmt.Errorf("unknown source %q", name)
+	}
+	return nil
+}
+
+func TestLoadChecksSources(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config string
+		want   string
+	}{
+		"default source": {
+			config: "topics:\n  - name: A\n    keywords: [video]\n",
+		},
+		"unknown source": {
+			config: "topics:\n  - name: A\n    source: arxive\n    keywords: [video]\n",
+			want:   `topic[0] (A) unknown source "arxive"`,
+		},
+		"source check": {
+			config: "topics:\n  - name: A\n    source: needs-query\n    keywords: [video]\n",
+			want:   "topic[0] (A) query must be set",
+		},
+		"unknown http key": {
+			config: "http:\n  arxive: {attempts: 2}\ntopics:\n  - name: A\n    keywords: [video]\n",
+			want:   `http: unknown source "arxive"`,
+		},
+	}
+	for name, tc := range cases {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(tc.config), 0o644); err != nil {
+			t.Fatalf("%s: write config: %v", name, err)
+		}
+		_, err := Load(path, knownSources{})
+		if tc.want == "" {
+			if err != nil {
+				t.Fatalf("%s: load config: %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+		// Without a checker the config itself is valid.
+		if _, err := Load(path, nil); err != nil {
+			t.Fatalf("%s: load config without a checker: %v", name, err)
+		}
+	}
+}
+
 func TestEffectiveMinScorePrecedence(t *testing.T) {
 	t.Parallel()
 
@@ -70,20 +423,99 @@ func TestEffectiveMinScorePrecedence(t *testing.T) {
 	topic := Topic{MinScore: 3}
 
 	if got := cfg.EffectiveMinScore(topic, 5); got != 5 {
-		t.Fatalf("cli override should win, got %d", got)
+		t.Fatalf("cli override should win, got %v", got)
 	}
 	if got := cfg.EffectiveMinScore(topic, 0); got != 3 {
-		t.Fatalf("topic min_score should win, got %d", got)
+		t.Fatalf("topic min_score should win, got %v", got)
 	}
 
 	cfg = Config{MinScore: 2}
 	topic = Topic{}
 	if got := cfg.EffectiveMinScore(topic, 0); got != 2 {
-		t.Fatalf("config min_score should win, got %d", got)
+		t.Fatalf("config min_score should win, got %v", got)
 	}
 
 	cfg = Config{}
 	if got := cfg.EffectiveMinScore(topic, 0); got != 1 {
-		t.Fatalf("default min_score should be 1, got %d", got)
+		t.Fatalf("default min_score should be 1, got %v", got)
+	}
+}
+
+func TestEffectiveSince(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cfg := Config{MaxAgeDays: 7}
+
+	if got := cfg.EffectiveSince(Topic{MaxAgeDays: 2}, now); !got.Equal(time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("topic max_age_days should win, got %v", got)
+	}
+	if got := cfg.EffectiveSince(Topic{}, now); !got.Equal(time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("config max_age_days should apply, got %v", got)
+	}
+	if got := (Config{}).EffectiveSince(Topic{}, now); !got.IsZero() {
+		t.Fatalf("expected no cutoff by default, got %v", got)
+	}
+}
+
+func TestEditKeywordsPatchesLinesInPlace(t *testing.T) {
+	t.Parallel()
+
+	source := `topics:
+  - name: "Video"   # main topic
+    source: "arxiv"
+    keywords:
+      - "video"       # plain
+      - {word: "4d", weight: 3}
+      - {word: "nerf"}
+      - word: 'gaussian'
+        max_hits: 2
+
+  - name: "Agents"
+    keywords: [agent]
+`
+	edited, err := EditKeywords([]byte(source), []KeywordEdit{
+		{Topic: "Video", Word: "video", Weight: 4},
+		{Topic: "Video", Word: "4D", Weight: 2},
+		{Topic: "Video", Word: "nerf", Weight: 2},
+		{Topic: "Video", Word: "gaussian", Weight: 5},
+		{Topic: "Video", Word: "world model", Weight: 1, Comment: "tune"},
+	})
+	if err != nil {
+		t.Fatalf("edit keywords: %v", err)
+	}
+
+	want := `topics:
+  - name: "Video"   # main topic
+    source: "arxiv"
+    keywords:
+      - {word: "video", weight: 4}       # plain
+      - {word: "4d", weight: 2}
+      - {word: "nerf", weight: 2}
+      - word: 'gaussian'
+        weight: 5
+        max_hits: 2
+      - {word: "world model", weight: 1}  # tune
+
+  - name: "Agents"
+    keywords: [agent]
+`
+	if string(edited) != want {
+		t.Fatalf("unexpected edit result:\n%s", edited)
+	}
+
+	cfg, err := Parse(edited)
+	if err != nil {
+		t.Fatalf("parse edited config: %v", err)
+	}
+	if got := cfg.Topics[0].Keywords[3]; got.Word != "gaussian" || got.Weight != 5 || got.MaxHits != 2 {
+		t.Fatalf("unexpected gaussian keyword after edit: %#v", got)
+	}
+
+	if _, err := EditKeywords([]byte(source), []KeywordEdit{{Topic: "Agents", Word: "agent", Weight: 2}}); err == nil {
+		t.Fatalf("expected flow-style keyword lists to be rejected")
+	}
+	if _, err := EditKeywords([]byte(source), []KeywordEdit{{Topic: "Missing", Word: "x", Weight: 2}}); err == nil {
+		t.Fatalf("expected an unknown topic to be rejected")
 	}
 }
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeywordEdit sets the weight of one of a topic's keywords, appending the
+// keyword to the topic when it does not have it yet. Comment, if set, is
+// written after an appended keyword.
+type KeywordEdit struct {
+	Topic   string
+	Word    string
+	Weight  int
+	Comment string
+}
+
+// EditKeywords applies edits to the YAML config source data. It patches the
+// affected lines in place rather than re-encoding the document, so comments,
+// quoting and layout elsewhere survive untouched. Keywords written as plain
+// strings become {word, weight} maps when their weight changes.
+func EditKeywords(data []byte, edits []KeywordEdit) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("config is empty")
+	}
+	topics := mappingValue(root.Content[0], "topics")
+	if topics == nil || topics.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("config has no topics list")
+	}
+
+	patch := &linePatch{lines: strings.Split(string(data), "\n"), inserts: map[int][]string{}}
+	for _, edit := range edits {
+		topic := findTopicNode(topics, edit.Topic)
+		if topic == nil {
+			return nil, fmt.Errorf("topic %q not found", edit.Topic)
+		}
+		keywords := mappingValue(topic, "keywords")
+		if keywords == nil || keywords.Kind != yaml.SequenceNode || keywords.Style&yaml.FlowStyle != 0 {
+			return nil, fmt.Errorf("topic %q: keywords must be a block list to be edited", edit.Topic)
+		}
+		if err := patch.keyword(keywords, edit); err != nil {
+			return nil, fmt.Errorf("topic %q: %w", edit.Topic, err)
+		}
+	}
+	return []byte(patch.apply()), nil
+}
+
+// linePatch collects edits against the original source lines. Replacements
+// keep the line count; inserts go after an original line (0-based) and are
+// applied last, so every node position stays valid while edits are planned.
+type linePatch struct {
+	lines   []string
+	inserts map[int][]string
+}
+
+func (p *linePatch) keyword(keywords *yaml.Node, edit KeywordEdit) error {
+	for _, item := range keywords.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			if !strings.EqualFold(keywordWord(item), edit.Word) {
+				continue
+			}
+			if edit.Weight == 1 {
+				return nil
+			}
+			source := p.scalarText(item)
+			return p.replace(item, source, fmt.Sprintf("{word: %s, weight: %d}", source, edit.Weight))
+		case yaml.MappingNode:
+			if !strings.EqualFold(keywordWord(item), edit.Word) {
+				continue
+			}
+			word := mappingValue(item, "word")
+			if weight := mappingValue(item, "weight"); weight != nil {
+				return p.replace(weight, p.scalarText(weight), fmt.Sprint(edit.Weight))
+			}
+			if item.Style&yaml.FlowStyle != 0 {
+				source := p.scalarText(word)
+				return p.replace(word, source, fmt.Sprintf("%s, weight: %d", source, edit.Weight))
+			}
+			indent := strings.Repeat(" ", item.Column-1)
+			p.inserts[word.Line-1] = append(p.inserts[word.Line-1], fmt.Sprintf("%sweight: %d", indent, edit.Weight))
+			return nil
+		case yaml.AliasNode:
+			if strings.EqualFold(keywordWord(item.Alias), edit.Word) {
+				return fmt.Errorf("keyword %q is an alias and must be edited by hand", edit.Word)
+			}
+		}
+	}
+
+	line := fmt.Sprintf("%s- {word: %q, weight: %d}", strings.Repeat(" ", keywords.Column-1), edit.Word, edit.Weight)
+	if edit.Comment != "" {
+		line += "  # " + edit.Comment
+	}
+	last := lastLine(keywords) - 1
+	p.inserts[last] = append(p.inserts[last], line)
+	return nil
+}
+
+// scalarText returns the source text of a single-line scalar node,
+// including its quotes.
+func (p *linePatch) scalarText(node *yaml.Node) string {
+	runes := []rune(p.lines[node.Line-1])
+	start := node.Column - 1
+	end := start + len([]rune(node.Value))
+	switch node.Style {
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		quote := runes[start]
+		for end = start + 1; end < len(runes); end++ {
+			if quote == '"' && runes[end] == '\\' {
+				end++
+				continue
+			}
+			if runes[end] == quote {
+				if quote == '\'' && end+1 < len(runes) && runes[end+1] == '\'' {
+					end++
+					continue
+				}
+				break
+			}
+		}
+		end++
+	}
+	if end > len(runes) {
+		end = len(runes)
+	}
+	return string(runes[start:end])
+}
+
+func (p *linePatch) replace(node *yaml.Node, old, text string) error {
+	runes := []rune(p.lines[node.Line-1])
+	start := node.Column - 1
+	end := start + len([]rune(old))
+	if end > len(runes) || string(runes[start:end]) != old {
+		return fmt.Errorf("line %d: cannot locate %q in the source", node.Line, old)
+	}
+	p.lines[node.Line-1] = string(runes[:start]) + text + string(runes[end:])
+	return nil
+}
+
+func (p *linePatch) apply() string {
+	at := make([]int, 0, len(p.inserts))
+	for line := range p.inserts {
+		at = append(at, line)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(at)))
+
+	lines := p.lines
+	for _, line := range at {
+		tail := append(append([]string(nil), p.inserts[line]...), lines[line+1:]...)
+		lines = append(lines[:line+1], tail...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func findTopicNode(topics *yaml.Node, name string) *yaml.Node {
+	for _, topic := range topics.Content {
+		if topic.Kind != yaml.MappingNode {
+			continue
+		}
+		if value := mappingValue(topic, "name"); value != nil && strings.TrimSpace(value.Value) == name {
+			return topic
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// keywordWord returns the word of a keyword node in either form.
+func keywordWord(node *yaml.Node) string {
+	if node.Kind == yaml.MappingNode {
+		node = mappingValue(node, "word")
+	}
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return strings.TrimSpace(node.Value)
+}
+
+// lastLine returns the last source line (1-based) spanned by node.
+func lastLine(node *yaml.Node) int {
+	line := node.Line
+	for _, child := range node.Content {
+		if l := lastLine(child); l > line {
+			line = l
+		}
+	}
+	return line
+}
@@ -2,9 +2,12 @@ package digest
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,6 +31,16 @@ var (
 	brokenDashBold = regexp.MustCompile(`(- )\s*\n+(\*\*)`)     // fix "- \n\n**text**"
 )
 
+// The HTML the digest wraps its score breakdown in. It is the only raw
+// HTML the PDF keeps (see markdownToHTML).
+const (
+	detailsOpen      = "<details>"
+	breakdownSummary = "<summary>Why this paper</summary>"
+	detailsClose     = "</details>"
+)
+
+var digestMarkup = map[string]bool{detailsOpen: true, breakdownSummary: true, detailsClose: true}
+
 // Q section default titles (Chinese)
 var qTitles = map[string]string{
 	"Q1": "这篇论文试图解决什么问题？",
@@ -77,37 +90,218 @@ func BuildMarkdown(date time.Time, papers []model.ScoredPaper) string {
 
 func writePaperMarkdown(builder *strings.Builder, num int, paper model.ScoredPaper) {
 	// Title
-	fmt.Fprintf(builder, "## %d. %s\n\n", num, paper.Paper.Title)
+	badge := ""
+	if paper.Updated {
+		badge = " `Updated`"
+	}
+	fmt.Fprintf(builder, "## %d. %s%s\n\n", num, paper.Paper.Title, badge)
 
 	// Metadata table
 	builder.WriteString("| Field | Value |\n")
 	builder.WriteString("|-------|-------|\n")
-	fmt.Fprintf(builder, "| Score | %d |\n", paper.Score)
+	fmt.Fprintf(builder, "| Score | %s |\n", formatScore(paper.Score))
 	if len(paper.Topics) > 0 {
 		fmt.Fprintf(builder, "| Topics | %s |\n", strings.Join(paper.Topics, ", "))
 	}
+	if len(paper.Paper.Authors) > 0 {
+		fmt.Fprintf(builder, "| Authors | %s |\n", tableCell(formatAuthors(paper.Paper.Authors, paper.FollowedAuthors)))
+	}
+	if len(paper.Paper.Affiliations) > 0 {
+		fmt.Fprintf(builder, "| Affiliations | %s |\n", tableCell(formatAffiliations(paper.Paper.Affiliations, paper.FollowedAffiliations)))
+	}
+	if len(paper.Paper.Categories) > 0 {
+		fmt.Fprintf(builder, "| Categories | %s |\n", tableCell(formatCategories(paper.Paper.PrimaryCategory, paper.Paper.Categories)))
+	}
 	if paper.Paper.URL != "" {
-		arxivID := paper.Paper.URL
-		if idx := strings.LastIndex(arxivID, "/"); idx >= 0 {
-			arxivID = arxivID[idx+1:]
+		label := paper.Paper.URL
+		if id, ok := model.ParseArxivID(label); ok {
+			label = id.Versioned()
+		} else if idx := strings.LastIndex(label, "/"); idx >= 0 {
+			label = label[idx+1:]
 		}
-		fmt.Fprintf(builder, "| URL | [%s](%s) |\n", arxivID, paper.Paper.URL)
+		fmt.Fprintf(builder, "| URL | [%s](%s) |\n", label, paper.Paper.URL)
+	}
+	if paper.Paper.PDFURL != "" {
+		fmt.Fprintf(builder, "| PDF | [pdf](%s) |\n", paper.Paper.PDFURL)
 	}
 	if !paper.Paper.PublishedAt.IsZero() {
 		fmt.Fprintf(builder, "| Published | %s |\n", paper.Paper.PublishedAt.Format("2006-01-02"))
 	}
+	if paper.Paper.Comment != "" {
+		fmt.Fprintf(builder, "| Comment | %s |\n", tableCell(paper.Paper.Comment))
+	}
+	if paper.Paper.JournalRef != "" {
+		fmt.Fprintf(builder, "| Journal | %s |\n", tableCell(paper.Paper.JournalRef))
+	}
+	if paper.Paper.DOI != "" {
+		fmt.Fprintf(builder, "| DOI | [%s](https://doi.org/%s) |\n", tableCell(paper.Paper.DOI), paper.Paper.DOI)
+	}
+	if citations := paper.Paper.Citations; citations != nil {
+		fmt.Fprintf(builder, "| Citations | %d (%d influential) |\n", citations.Count, citations.Influential)
+		if citations.Venue != "" {
+			fmt.Fprintf(builder, "| Venue | %s |\n", tableCell(citations.Venue))
+		}
+		// arXiv papers already link their arXiv PDF.
+		if citations.OpenAccessPDF != "" && (paper.Paper.PDFURL == "" || !strings.Contains(citations.OpenAccessPDF, "arxiv.org/")) {
+			fmt.Fprintf(builder, "| Open access | [pdf](%s) |\n", citations.OpenAccessPDF)
+		}
+	}
+	if label := announcementLabels[paper.Paper.Announcement]; label != "" {
+		fmt.Fprintf(builder, "| Listing | %s |\n", label)
+	}
+	if paper.Updated {
+		fmt.Fprintf(builder, "| Updated | %s |\n", formatRevision(paper))
+	}
 	builder.WriteString("\n")
 
-	// Format summary with Q sections
-	summary := paper.Paper.Summary
-	if summary == "" {
+	writeScoreBreakdown(builder, paper.Breakdown)
+
+	if paper.Paper.Abstract == "" && paper.Paper.AISummary == "" {
 		builder.WriteString("*(No summary available)*\n\n")
 		return
 	}
 
+	if paper.Paper.Abstract != "" {
+		builder.WriteString("### Abstract\n\n")
+		builder.WriteString(paper.Paper.Abstract)
+		builder.WriteString("\n\n")
+	}
+
+	if paper.Paper.AISummary != "" {
+		writeAISummary(builder, paper.Paper.AISummary)
+	}
+}
+
+// formatScore prints whole scores without decimals and fractional
+// (BM25/TF-IDF) scores with two.
+func formatScore(score float64) string {
+	if score == math.Trunc(score) {
+		return strconv.FormatFloat(score, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(score, 'f', 2, 64)
+}
+
+// maxListedAuthors is how many authors the metadata table names before
+// abbreviating the list.
+const maxListedAuthors = 8
+
+// formatAuthors lists authors with followed ones in bold. A followed author
+// past the cut-off is still named, after the others.
+func formatAuthors(authors, followed []string) string {
+	isFollowed := make(map[string]bool, len(followed))
+	for _, author := range followed {
+		isFollowed[author] = true
+	}
+
+	parts := make([]string, 0, maxListedAuthors)
+	for i, author := range authors {
+		switch {
+		case isFollowed[author]:
+			parts = append(parts, "**"+author+"**")
+		case i < maxListedAuthors:
+			parts = append(parts, author)
+		}
+	}
+	if len(authors) <= maxListedAuthors {
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprintf("%s et al. (%d authors)", strings.Join(parts, ", "), len(authors))
+}
+
+// formatAffiliations lists every affiliation, the followed ones in bold.
+func formatAffiliations(affiliations, followed []string) string {
+	parts := make([]string, len(affiliations))
+	for i, affiliation := range affiliations {
+		parts[i] = affiliation
+		for _, name := range followed {
+			if name == affiliation {
+				parts[i] = "**" + affiliation + "**"
+				break
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatCategories lists the primary category first, in bold.
+func formatCategories(primary string, categories []string) string {
+	parts := make([]string, 0, len(categories))
+	if primary != "" {
+		parts = append(parts, "**"+primary+"**")
+	}
+	for _, category := range categories {
+		if category != primary {
+			parts = append(parts, category)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// tableCell keeps free-form text from breaking out of a table cell.
+func tableCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// announcementLabels describe how a paper appeared in the arXiv listing.
+var announcementLabels = map[string]string{
+	model.AnnounceNew:          "new",
+	model.AnnounceCross:        "cross-list",
+	model.AnnounceReplace:      "replacement",
+	model.AnnounceReplaceCross: "replacement (cross-list)",
+}
+
+// formatRevision describes a re-surfaced revision, e.g. "v1 → v2, 2026-03-02".
+func formatRevision(paper model.ScoredPaper) string {
+	var parts []string
+	if paper.Paper.Version > 0 {
+		version := fmt.Sprintf("v%d", paper.Paper.Version)
+		if paper.PreviousVersion > 0 {
+			version = fmt.Sprintf("v%d → %s", paper.PreviousVersion, version)
+		}
+		parts = append(parts, version)
+	}
+	if !paper.Paper.UpdatedAt.IsZero() {
+		parts = append(parts, paper.Paper.UpdatedAt.Format("2006-01-02"))
+	}
+	if len(parts) == 0 {
+		return "revised"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeScoreBreakdown renders the per-keyword score explanation as a
+// collapsible block, strongest contributions first.
+func writeScoreBreakdown(builder *strings.Builder, hits []model.ScoreHit) {
+	if len(hits) == 0 {
+		return
+	}
+
+	sorted := make([]model.ScoreHit, len(hits))
+	copy(sorted, hits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Points > sorted[j].Points
+	})
+
+	builder.WriteString(detailsOpen + "\n" + breakdownSummary + "\n\n")
+	builder.WriteString("| Topic | Keyword | Field | Hits | Weight | Points |\n")
+	builder.WriteString("|-------|---------|-------|------|--------|--------|\n")
+	for _, hit := range sorted {
+		field := hit.Field
+		if hit.FieldWeight > 1 {
+			field = fmt.Sprintf("%s ×%d", hit.Field, hit.FieldWeight)
+		}
+		fmt.Fprintf(builder, "| %s | %s | %s | %d | %d | %s |\n", tableCell(hit.Topic), tableCell(hit.Keyword), field, hit.Hits, hit.Weight, formatScore(hit.Points))
+	}
+	builder.WriteString("\n" + detailsClose + "\n\n")
+}
+
+// writeAISummary renders a Kimi-style summary as its Q1-Q6 sections, or
+// as-is under an "AI Summary" heading when it has no Q markers.
+func writeAISummary(builder *strings.Builder, summary string) {
 	sections := splitQSections(summary)
 	if len(sections) == 0 {
-		// No Q sections found — output as-is
+		builder.WriteString("### AI Summary\n\n")
 		builder.WriteString(summary)
 		builder.WriteString("\n\n")
 		return
@@ -0,0 +1,140 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+func TestBuildMarkdownRendersAbstractAndAISummary(t *testing.T) {
+	papers := []model.ScoredPaper{{
+		Paper: model.Paper{
+			Title:     "Paper",
+			Abstract:  "Original arXiv abstract.",
+			AISummary: "Q1: 这篇论文试图解决什么问题？\n问题描述\n\nQ2: 有哪些相关研究？\n相关工作",
+		},
+		Score: 3,
+	}}
+
+	content := BuildMarkdown(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), papers)
+	for _, want := range []string{"### Abstract\n\nOriginal arXiv abstract.", "### Q1: 这篇论文试图解决什么问题？\n\n问题描述", "### Q2: 有哪些相关研究？"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("digest missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Index(content, "### Abstract") > strings.Index(content, "### Q1") {
+		t.Fatalf("abstract should precede the AI summary:\n%s", content)
+	}
+}
+
+func TestBuildMarkdownRendersScoreBreakdown(t *testing.T) {
+	papers := []model.ScoredPaper{{
+		Paper: model.Paper{Title: "Paper", Abstract: "abstract"},
+		Score: 13,
+		Breakdown: []model.ScoreHit{
+			{Topic: "3D", Keyword: "video", Field: "abstract", Hits: 3, Weight: 1, Points: 3},
+			{Topic: "3D", Keyword: "3D", Field: "title", Hits: 1, Weight: 10, Points: 10},
+			{Topic: "3D|4D", Keyword: "gs|splat\nting", Field: "title", Hits: 1, Weight: 1, Points: 1},
+		},
+	}}
+
+	content := BuildMarkdown(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), papers)
+	if !strings.Contains(content, "<details>\n<summary>Why this paper</summary>") {
+		t.Fatalf("digest missing breakdown block:\n%s", content)
+	}
+	title := strings.Index(content, "| 3D | 3D | title | 1 | 10 | 10 |")
+	abstract := strings.Index(content, "| 3D | video | abstract | 3 | 1 | 3 |")
+	if title < 0 || abstract < 0 || title > abstract {
+		t.Fatalf("breakdown rows missing or not sorted by points:\n%s", content)
+	}
+	if !strings.Contains(content, "| 3D\\|4D | gs\\|splat ting | title | 1 | 1 | 1 |") {
+		t.Fatalf("breakdown cells should be escaped:\n%s", content)
+	}
+}
+
+func TestFormatScore(t *testing.T) {
+	cases := map[float64]string{13: "13", 2.5: "2.50", 1.23456: "1.23", -2: "-2"}
+	for in, want := range cases {
+		if got := formatScore(in); got != want {
+			t.Fatalf("formatScore(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildMarkdownMarksUpdatedPapers(t *testing.T) {
+	papers := []model.ScoredPaper{{
+		Paper:           model.Paper{Title: "Streaming 4D", Version: 3, UpdatedAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		Score:           4,
+		Updated:         true,
+		PreviousVersion: 1,
+	}}
+
+	md := BuildMarkdown(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), papers)
+	if !strings.Contains(md, "## 1. Streaming 4D `Updated`") {
+		t.Fatalf("expected Updated badge in heading:\n%s", md)
+	}
+	if !strings.Contains(md, "| Updated | v1 → v3, 2026-03-02 |") {
+		t.Fatalf("expected revision row:\n%s", md)
+	}
+}
+
+func TestBuildMarkdownRendersMetadata(t *testing.T) {
+	authors := []string{"A1", "A2", "A3", "A4", "A5", "A6", "A7", "A8", "A9"}
+	papers := []model.ScoredPaper{{
+		Paper: model.Paper{
+			Title:           "Streaming 4D",
+			URL:             "http://arxiv.org/abs/2602.23153v2",
+			Authors:         authors,
+			PrimaryCategory: "cs.CV",
+			Categories:      []string{"cs.CV", "cs.GR"},
+			Comment:         "CVPR 2026 | code: https://github.com/example/s4d",
+			JournalRef:      "CVPR 2026",
+			DOI:             "10.1000/s4d",
+			PDFURL:          "http://arxiv.org/pdf/2602.23153v2",
+			Announcement:    model.AnnounceCross,
+			Citations:       &model.Citations{Count: 12, Influential: 3, Venue: "CVPR", OpenAccessPDF: "https://example.org/s4d.pdf"},
+		},
+		Score: 3,
+	}}
+
+	md := BuildMarkdown(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), papers)
+	for _, want := range []string{
+		"| Authors | A1, A2, A3, A4, A5, A6, A7, A8 et al. (9 authors) |",
+		"| Categories | **cs.CV**, cs.GR |",
+		"| URL | [2602.23153v2](http://arxiv.org/abs/2602.23153v2) |",
+		"| PDF | [pdf](http://arxiv.org/pdf/2602.23153v2) |",
+		"| Comment | CVPR 2026 \\| code: https://github.com/example/s4d |",
+		"| Journal | CVPR 2026 |",
+		"| DOI | [10.1000/s4d](https://doi.org/10.1000/s4d) |",
+		"| Listing | cross-list |",
+		"| Citations | 12 (3 influential) |",
+		"| Venue | CVPR |",
+		"| Open access | [pdf](https://example.org/s4d.pdf) |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("missing %q in:\n%s", want, md)
+		}
+	}
+}
+
+func TestBuildMarkdownHighlightsFollowedAuthors(t *testing.T) {
+	authors := []string{"A1", "A2", "A3", "A4", "A5", "A6", "A7", "A8", "A9", "Zoë Müller"}
+	papers := []model.ScoredPaper{{
+		Paper:                model.Paper{Title: "Streaming 4D", Authors: authors, Affiliations: []string{"Meta AI (FAIR)", "ETH Zurich"}},
+		Score:                3,
+		FollowedAuthors:      []string{"A2", "Zoë Müller"},
+		FollowedAffiliations: []string{"ETH Zurich"},
+	}}
+
+	md := BuildMarkdown(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), papers)
+	for _, want := range []string{
+		"| Authors | A1, **A2**, A3, A4, A5, A6, A7, A8, **Zoë Müller** et al. (10 authors) |",
+		"| Affiliations | Meta AI (FAIR), **ETH Zurich** |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("missing %q in:\n%s", want, md)
+		}
+	}
+}
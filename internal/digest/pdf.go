@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 	"github.com/kyc001/paper-radar/internal/model"
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
 )
 
 // WritePDF generates a PDF by converting the markdown digest to HTML and
@@ -22,16 +26,11 @@ func WritePDF(outputDir string, date time.Time, papers []model.ScoredPaper) (str
 	filename := date.Format("2006-01-02") + ".pdf"
 	path := filepath.Join(outputDir, filename)
 
-	md := BuildMarkdown(date, papers)
-
-	// Markdown → HTML body
-	converter := goldmark.New(goldmark.WithExtensions(extension.Table))
-	var buf bytes.Buffer
-	if err := converter.Convert([]byte(md), &buf); err != nil {
-		return "", fmt.Errorf("markdown to html: %w", err)
+	body, err := markdownToHTML(BuildMarkdown(date, papers))
+	if err != nil {
+		return "", err
 	}
-
-	html := wrapHTML(buf.String())
+	document := wrapHTML(body)
 
 	// Write HTML to temp file so Chrome can load external resources (KaTeX CDN)
 	tmpFile, err := os.CreateTemp("", "paper-radar-*.html")
@@ -41,7 +40,7 @@ func WritePDF(outputDir string, date time.Time, papers []model.ScoredPaper) (str
 	tmpPath := tmpFile.Name()
 	defer os.Remove(tmpPath)
 
-	if _, err := tmpFile.WriteString(html); err != nil {
+	if _, err := tmpFile.WriteString(document); err != nil {
 		tmpFile.Close()
 		return "", fmt.Errorf("write temp file: %w", err)
 	}
@@ -77,6 +76,77 @@ func WritePDF(outputDir string, date time.Time, papers []model.ScoredPaper) (str
 	return path, nil
 }
 
+// markdownToHTML converts a digest to an HTML body. Raw HTML in it is
+// escaped except for the markup BuildMarkdown emits itself (see
+// digestMarkup): the rest comes from paper text such as feed titles,
+// abstracts and Kimi summaries, which Chrome must not run.
+func markdownToHTML(md string) (string, error) {
+	converter := goldmark.New(
+		goldmark.WithExtensions(extension.Table),
+		goldmark.WithRendererOptions(renderer.WithNodeRenderers(util.Prioritized(rawHTMLRenderer{}, 100))),
+	)
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(md), &buf); err != nil {
+		return "", fmt.Errorf("markdown to html: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// rawHTMLRenderer renders HTML blocks and inline HTML, taking over from
+// goldmark's renderer, which can only drop or pass them all.
+type rawHTMLRenderer struct{}
+
+func (rawHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, renderHTMLBlock)
+	reg.Register(ast.KindRawHTML, renderRawHTML)
+}
+
+func renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.HTMLBlock)
+	var lines [][]byte
+	for i := 0; i < n.Lines().Len(); i++ {
+		segment := n.Lines().At(i)
+		lines = append(lines, segment.Value(source))
+	}
+	if n.HasClosure() {
+		lines = append(lines, n.ClosureLine.Value(source))
+	}
+
+	ours := true
+	for _, line := range lines {
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" && !digestMarkup[trimmed] {
+			ours = false
+		}
+	}
+	if ours {
+		for _, line := range lines {
+			_, _ = w.Write(line)
+		}
+		return ast.WalkContinue, nil
+	}
+	_, _ = w.WriteString("<p>")
+	for _, line := range lines {
+		_, _ = w.Write(util.EscapeHTML(line))
+	}
+	_, _ = w.WriteString("</p>\n")
+	return ast.WalkContinue, nil
+}
+
+func renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*ast.RawHTML)
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		_, _ = w.Write(util.EscapeHTML(segment.Value(source)))
+	}
+	return ast.WalkSkipChildren, nil
+}
+
 func wrapHTML(body string) string {
 	return `<!DOCTYPE html>
 <html lang="zh-CN"><head><meta charset="UTF-8">
@@ -110,6 +180,8 @@ code { background: #f3f4f6; padding: 2px 6px; border-radius: 3px; font-size: 10p
 strong { color: #111827; }
 hr { border: none; border-top: 2px dashed #d1d5db; margin: 32px 0; page-break-after: avoid; }
 a { color: #2563eb; text-decoration: none; }
+details { margin: 12px 0; }
+summary { font-weight: 700; color: #1d4ed8; }
 @media print {
   h2 { page-break-before: always; }
   h2:first-of-type { page-break-before: avoid; }
@@ -119,6 +191,8 @@ a { color: #2563eb; text-decoration: none; }
 </head><body>` + body + `
 <script>
 document.addEventListener("DOMContentLoaded", function() {
+  // Print collapsible blocks expanded
+  document.querySelectorAll("details").forEach(function(d) { d.open = true; });
   renderMathInElement(document.body, {
     delimiters: [
       {left: "$$", right: "$$", display: true},
@@ -0,0 +1,38 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+func TestMarkdownToHTMLEscapesPaperHTML(t *testing.T) {
+	t.Parallel()
+
+	papers := []model.ScoredPaper{{
+		Paper: model.Paper{
+			Title:     `Agents <img src=x onerror="alert(1)">`,
+			Abstract:  "<script>alert(document.cookie)</script>\n\nAgents with <img src=x onerror=alert(2)> memory.",
+			AISummary: "<iframe src=\"https://evil.example\"></iframe>",
+		},
+		Score:     3,
+		Breakdown: []model.ScoreHit{{Topic: "Agents", Keyword: "agent", Field: "title", Hits: 1, Weight: 3, Points: 3}},
+	}}
+
+	body, err := markdownToHTML(BuildMarkdown(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), papers))
+	if err != nil {
+		t.Fatalf("markdown to html: %v", err)
+	}
+	for _, unsafe := range []string{"<script", "<img", "<iframe"} {
+		if strings.Contains(body, unsafe) {
+			t.Fatalf("paper HTML %q should be escaped in:\n%s", unsafe, body)
+		}
+	}
+	for _, want := range []string{"&lt;script&gt;alert(document.cookie)&lt;/script&gt;", "&lt;img src=x onerror=alert(2)&gt;", "<details>", "<summary>Why this paper</summary>", "</details>"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+}
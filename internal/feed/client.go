@@ -0,0 +1,52 @@
+// Package feed reads papers from generic RSS, Atom and JSON Feed feeds:
+// lab blogs, accepted-paper lists, bioRxiv subject feeds and the like.
+// Items are mapped onto papers through a configurable field mapping.
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kyc001/paper-radar/internal/httpclient"
+)
+
+// maxFeedBytes bounds a downloaded feed.
+const maxFeedBytes = 20 << 20
+
+type Client struct {
+	httpClient *httpclient.Client
+}
+
+func NewClient(policy httpclient.Policy) *Client {
+	return &Client{httpClient: httpclient.New("feed", policy)}
+}
+
+// fetchItems downloads the feed at feedURL and returns its items in feed
+// order.
+func (c *Client) fetchItems(ctx context.Context, feedURL string) ([]item, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "paper-radar/0.2.0")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes))
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
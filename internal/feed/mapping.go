@@ -0,0 +1,254 @@
+package feed
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Names is a list of item fields tried in order; the first one present
+// wins (authors and categories take every value of it). In YAML it is a
+// field name or a list of them.
+type Names []string
+
+func (n *Names) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*n = Names{node.Value}
+		return nil
+	}
+	var names []string
+	if err := node.Decode(&names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
+// Fields maps paper fields onto item fields. Fields left empty use
+// defaultFields, which covers RSS 2.0 and 1.0, Atom and JSON Feed.
+type Fields struct {
+	ID         Names `yaml:"id"`
+	Title      Names `yaml:"title"`
+	Abstract   Names `yaml:"abstract"`
+	URL        Names `yaml:"url"`
+	Published  Names `yaml:"published"`
+	Updated    Names `yaml:"updated"`
+	Authors    Names `yaml:"authors"`
+	Categories Names `yaml:"categories"`
+	DOI        Names `yaml:"doi"`
+	PDF        Names `yaml:"pdf"`
+}
+
+// defaultFields is the mapping used for fields a topic does not set.
+var defaultFields = Fields{
+	ID:         Names{"guid", "id", "dc:identifier"},
+	Title:      Names{"title", "dc:title"},
+	Abstract:   Names{"summary", "description", "content_text", "content:encoded", "content", "content_html", "dc:description"},
+	URL:        Names{"link", "url", "permalink", "external_url"},
+	Published:  Names{"published", "pubDate", "date_published", "dc:date", "prism:publicationDate", "issued", "updated"},
+	Updated:    Names{"updated", "date_modified", "atom:updated", "dcterms:modified"},
+	Authors:    Names{"author", "dc:creator"},
+	Categories: Names{"category", "tags", "dc:subject"},
+	DOI:        Names{"prism:doi", "arxiv:doi", "dc:identifier", "doi"},
+	PDF:        Names{"pdf"},
+}
+
+// withDefaults fills the fields f leaves empty from defaultFields.
+func (f Fields) withDefaults() Fields {
+	fill := func(names *Names, fallback Names) {
+		if len(*names) == 0 {
+			*names = fallback
+		}
+	}
+	fill(&f.ID, defaultFields.ID)
+	fill(&f.Title, defaultFields.Title)
+	fill(&f.Abstract, defaultFields.Abstract)
+	fill(&f.URL, defaultFields.URL)
+	fill(&f.Published, defaultFields.Published)
+	fill(&f.Updated, defaultFields.Updated)
+	fill(&f.Authors, defaultFields.Authors)
+	fill(&f.Categories, defaultFields.Categories)
+	fill(&f.DOI, defaultFields.DOI)
+	fill(&f.PDF, defaultFields.PDF)
+	return f
+}
+
+func (it item) first(names Names) string {
+	for _, name := range names {
+		if values := it[name]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func (it item) all(names Names) []string {
+	for _, name := range names {
+		if values := it[name]; len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// toPaper maps it, an item of the feed on feedHost, onto a paper. ok is
+// false when the item has nothing to derive an ID from.
+func (f Fields) toPaper(feedHost string, it item) (model.Paper, bool) {
+	doi := findDOI(it.all(f.DOI))
+	link := it.first(f.URL)
+	id, version := stableID(feedHost, it.all(f.ID), doi, link)
+	if id == "" {
+		return model.Paper{}, false
+	}
+
+	paper := model.Paper{
+		ID:          id,
+		Version:     version,
+		Title:       plainText(it.first(f.Title)),
+		Abstract:    plainText(it.first(f.Abstract)),
+		URL:         link,
+		PublishedAt: parseDate(it.first(f.Published)),
+		UpdatedAt:   parseDate(it.first(f.Updated)),
+		DOI:         doi,
+		PDFURL:      it.first(f.PDF),
+	}
+	for _, author := range it.all(f.Authors) {
+		// bioRxiv and others put every author in one element.
+		for _, name := range strings.Split(author, ";") {
+			if name = plainText(name); name != "" {
+				paper.Authors = append(paper.Authors, name)
+			}
+		}
+	}
+	for _, category := range it.all(f.Categories) {
+		if category = plainText(category); category != "" {
+			paper.Categories = append(paper.Categories, category)
+		}
+	}
+	if len(paper.Categories) > 0 {
+		paper.PrimaryCategory = paper.Categories[0]
+	}
+	return paper, true
+}
+
+// stableID derives an ID that stays the same across fetches and matches
+// the other sources where it can: the arXiv ID when an ID or link is an
+// arXiv one (so the paper dedups against arxiv topics), then "doi:" and the
+// DOI. Anything else is opaque and namespaced under "feed:" so it cannot
+// collide with another source's IDs: the item's guid or id, then its link,
+// as "feed:" and the normalized URL without its scheme, or for a guid that
+// is not a URL, "feed:", the feed's host and the guid.
+func stableID(feedHost string, ids []string, doi, link string) (string, int) {
+	for _, candidate := range append(append([]string(nil), ids...), link) {
+		if strings.Contains(strings.ToLower(candidate), "arxiv") {
+			if id, ok := model.ParseArxivID(candidate); ok {
+				return id.Base, id.Version
+			}
+		}
+	}
+	if doi != "" {
+		return model.DOIPrefix + strings.ToLower(doi), 0
+	}
+	if len(ids) > 0 {
+		link = ids[0]
+	}
+	link = strings.TrimSpace(link)
+	switch {
+	case link == "":
+		return "", 0
+	case looksLikeURL(link):
+		normalized := normalizeLink(link)
+		if _, rest, ok := strings.Cut(normalized, "://"); ok {
+			normalized = rest
+		}
+		return model.FeedPrefix + normalized, 0
+	}
+	return model.FeedPrefix + feedHost + "/" + link, 0
+}
+
+var doiRe = regexp.MustCompile(`\b(10\.[0-9]{4,9}/[^\s"<>]+)`)
+
+// findDOI returns the first DOI among values, which may be bare DOIs,
+// "doi:" URIs or doi.org links.
+func findDOI(values []string) string {
+	for _, value := range values {
+		if m := doiRe.FindStringSubmatch(value); m != nil {
+			doi, err := url.PathUnescape(m[1])
+			if err != nil {
+				doi = m[1]
+			}
+			return strings.TrimRight(doi, ".,;")
+		}
+	}
+	return ""
+}
+
+// normalizeLink drops what varies between fetches of the same link:
+// fragments, tracking parameters and a trailing slash.
+func normalizeLink(link string) string {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || parsed.Host == "" {
+		return strings.TrimSpace(link)
+	}
+	parsed.Fragment = ""
+	query := parsed.Query()
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || key == "rss" {
+			query.Del(key)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	return parsed.String()
+}
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// plainText strips markup and entities and collapses whitespace, for the
+// HTML many feeds put in titles and descriptions.
+func plainText(value string) string {
+	value = htmlTagRe.ReplaceAllString(value, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(value)), " ")
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseDate parses the date formats RSS, Atom, Dublin Core and JSON Feed
+// use; anything else reads as unknown.
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// check rejects field names that cannot match anything.
+func (f Fields) check() error {
+	for _, names := range []Names{f.ID, f.Title, f.Abstract, f.URL, f.Published, f.Updated, f.Authors, f.Categories, f.DOI, f.PDF} {
+		for _, name := range names {
+			if strings.TrimSpace(name) == "" {
+				return fmt.Errorf("source_options: empty field name")
+			}
+		}
+	}
+	return nil
+}
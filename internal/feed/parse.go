@@ -0,0 +1,294 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// item is one feed entry flattened to its elements' text, keyed by field
+// name as the mapping refers to it: the element's local name for the
+// feed's own vocabulary ("title", "pubDate", "summary"), "prefix:name" for
+// the common extension namespaces ("dc:creator", "prism:doi"). Repeated
+// elements keep every value in order.
+type item map[string][]string
+
+func (it item) add(key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		it[key] = append(it[key], value)
+	}
+}
+
+// namespacePrefixes names the extension namespaces feeds commonly use.
+// Elements in other foreign namespaces are keyed by their local name.
+var namespacePrefixes = map[string]string{
+	"http://purl.org/dc/elements/1.1/":               "dc",
+	"http://purl.org/dc/terms/":                      "dcterms",
+	"http://purl.org/rss/1.0/modules/content/":       "content",
+	"http://prismstandard.org/namespaces/basic/2.0/": "prism",
+	"http://prismstandard.org/namespaces/1.2/basic/": "prism",
+	"http://arxiv.org/schemas/atom":                  "arxiv",
+	"http://www.w3.org/2005/Atom":                    "atom",
+	"http://search.yahoo.com/mrss/":                  "media",
+}
+
+// parse decodes an RSS (0.9x, 1.0 or 2.0), Atom or JSON Feed document.
+func parse(data []byte) ([]item, error) {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseJSON(trimmed)
+	}
+	return parseXML(trimmed)
+}
+
+// xmlNode is any XML element with its attributes, text and children.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+func (n xmlNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// parseXML collects the <item>s of an RSS feed or the <entry>s of an Atom
+// feed, wherever they sit below the root.
+func parseXML(data []byte) ([]item, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charsetReader
+
+	var entryName string
+	var items []item
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse feed: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if entryName == "" {
+			switch start.Name.Local {
+			case "rss", "RDF":
+				entryName = "item"
+			case "feed":
+				entryName = "entry"
+			default:
+				return nil, fmt.Errorf("parse feed: <%s> is not an RSS or Atom root element", start.Name.Local)
+			}
+			continue
+		}
+		if start.Name.Local != entryName {
+			continue
+		}
+
+		var node xmlNode
+		if err := decoder.DecodeElement(&node, &start); err != nil {
+			return nil, fmt.Errorf("parse feed: %w", err)
+		}
+		items = append(items, flattenXML(node))
+	}
+	if entryName == "" {
+		return nil, fmt.Errorf("parse feed: empty document")
+	}
+	return items, nil
+}
+
+// flattenXML turns an entry element into an item. Elements in the entry's
+// own namespace keep their local name. Links and enclosures are keyed by
+// what they point at: "link" for the entry's page and "pdf" for a PDF.
+func flattenXML(entry xmlNode) item {
+	it := item{}
+	for _, child := range entry.Children {
+		key := child.XMLName.Local
+		if space := child.XMLName.Space; space != "" && space != entry.XMLName.Space {
+			if prefix, ok := namespacePrefixes[space]; ok {
+				key = prefix + ":" + key
+			}
+		}
+
+		switch key {
+		case "link", "atom:link":
+			href := child.attr("href")
+			if href == "" {
+				it.add("link", child.Text)
+				continue
+			}
+			switch {
+			case child.attr("type") == "application/pdf" || child.attr("title") == "pdf":
+				it.add("pdf", href)
+			case child.attr("rel") == "" || child.attr("rel") == "alternate":
+				it.add("link", href)
+			}
+		case "enclosure", "media:content":
+			if child.attr("type") == "application/pdf" {
+				it.add("pdf", child.attr("url"))
+			}
+		case "author", "contributor":
+			// Atom nests the name; RSS gives "email (Name)" as text.
+			if name := childText(child, "name"); name != "" {
+				it.add("author", name)
+			} else {
+				it.add("author", rssAuthorName(child.Text))
+			}
+		case "category":
+			if term := child.attr("term"); term != "" {
+				it.add(key, term)
+			} else {
+				it.add(key, child.Text)
+			}
+		case "guid":
+			it.add(key, child.Text)
+			if !strings.EqualFold(child.attr("isPermaLink"), "false") && looksLikeURL(child.Text) {
+				it.add("permalink", child.Text)
+			}
+		default:
+			it.add(key, child.Text)
+		}
+	}
+	return it
+}
+
+func childText(node xmlNode, name string) string {
+	for _, child := range node.Children {
+		if child.XMLName.Local == name {
+			return strings.TrimSpace(child.Text)
+		}
+	}
+	return ""
+}
+
+// rssAuthorName returns the name of an RSS author written as
+// "email (Name)", or the text unchanged.
+func rssAuthorName(text string) string {
+	text = strings.TrimSpace(text)
+	if open := strings.Index(text, "("); open > 0 && strings.HasSuffix(text, ")") && strings.Contains(text[:open], "@") {
+		return strings.TrimSpace(text[open+1 : len(text)-1])
+	}
+	return text
+}
+
+// charsetReader lets feeds declare Latin-1 as well as UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+// jsonFeed is a JSON Feed (1.0 or 1.1) document.
+type jsonFeed struct {
+	Version string     `json:"version"`
+	Items   []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	ID            json.RawMessage `json:"id"`
+	URL           string          `json:"url"`
+	ExternalURL   string          `json:"external_url"`
+	Title         string          `json:"title"`
+	ContentText   string          `json:"content_text"`
+	ContentHTML   string          `json:"content_html"`
+	Summary       string          `json:"summary"`
+	DatePublished string          `json:"date_published"`
+	DateModified  string          `json:"date_modified"`
+	Author        *jsonAuthor     `json:"author"`
+	Authors       []jsonAuthor    `json:"authors"`
+	Tags          []string        `json:"tags"`
+	Attachments   []struct {
+		URL      string `json:"url"`
+		MIMEType string `json:"mime_type"`
+	} `json:"attachments"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+// parseJSON flattens JSON Feed items under their JSON member names, with
+// authors under "author" and PDF attachments under "pdf" as for XML.
+func parseJSON(data []byte) ([]item, error) {
+	var feed jsonFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("parse feed: %w", err)
+	}
+	if !strings.HasPrefix(feed.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("parse feed: not a JSON Feed (version %q)", feed.Version)
+	}
+
+	items := make([]item, 0, len(feed.Items))
+	for _, entry := range feed.Items {
+		it := item{}
+		it.add("id", jsonID(entry.ID))
+		it.add("url", entry.URL)
+		it.add("external_url", entry.ExternalURL)
+		it.add("title", entry.Title)
+		it.add("summary", entry.Summary)
+		it.add("content_text", entry.ContentText)
+		it.add("content_html", entry.ContentHTML)
+		it.add("date_published", entry.DatePublished)
+		it.add("date_modified", entry.DateModified)
+		if entry.Author != nil {
+			it.add("author", entry.Author.Name)
+		}
+		for _, author := range entry.Authors {
+			it.add("author", author.Name)
+		}
+		for _, tag := range entry.Tags {
+			it.add("tags", tag)
+		}
+		for _, attachment := range entry.Attachments {
+			if attachment.MIMEType == "application/pdf" {
+				it.add("pdf", attachment.URL)
+			}
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// jsonID reads an item id. The spec says it is a string, but numbers are
+// common.
+func jsonID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	if len(raw) > 0 && raw[0] != 'n' {
+		return string(raw)
+	}
+	return ""
+}
+
+func looksLikeURL(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
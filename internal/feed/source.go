@@ -0,0 +1,102 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/source"
+)
+
+func init() {
+	source.Register("feed", func(env source.Env) source.Source {
+		return &topicSource{client: NewClient(env.HTTP("feed"))}
+	})
+}
+
+// Options are the feed source's source_options.
+type Options struct {
+	// Fields overrides the default item-to-paper field mapping.
+	Fields Fields `yaml:"fields"`
+}
+
+// topicSource reads a topic's query as a feed URL.
+type topicSource struct {
+	client *Client
+}
+
+func (s *topicSource) Check(q source.TopicQuery) error {
+	if _, err := feedURL(q); err != nil {
+		return err
+	}
+	_, err := options(q)
+	return err
+}
+
+// Fetch returns the feed's items as papers, in feed order. Items with
+// nothing to derive an ID from are skipped, as are items published before
+// q.Since.
+func (s *topicSource) Fetch(ctx context.Context, q source.TopicQuery) ([]model.Paper, error) {
+	target, err := feedURL(q)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := options(q)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.client.fetchItems(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := opts.Fields.withDefaults()
+	host := feedHost(target)
+	var papers []model.Paper
+	for _, it := range items {
+		if q.MaxResults > 0 && len(papers) >= q.MaxResults {
+			break
+		}
+		paper, ok := fields.toPaper(host, it)
+		if !ok {
+			continue
+		}
+		if !q.Since.IsZero() && !paper.PublishedAt.IsZero() && paper.PublishedAt.Before(q.Since) {
+			continue
+		}
+		papers = append(papers, paper)
+	}
+	return papers, nil
+}
+
+func (s *topicSource) Capabilities() source.Capabilities {
+	return source.Capabilities{DateCutoff: true}
+}
+
+func feedURL(q source.TopicQuery) (string, error) {
+	parsed, err := url.Parse(q.Query)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("query must be the feed's http(s) URL, got %q", q.Query)
+	}
+	return parsed.String(), nil
+}
+
+// feedHost is the host opaque guids are namespaced under, so the same guid
+// in two feeds names two papers.
+func feedHost(target string) string {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+func options(q source.TopicQuery) (Options, error) {
+	var opts Options
+	if err := q.Options.Decode(&opts); err != nil {
+		return Options{}, err
+	}
+	return opts, opts.Fields.check()
+}
@@ -0,0 +1,203 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/source"
+	"gopkg.in/yaml.v3"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:prism="http://prismstandard.org/namespaces/basic/2.0/">
+  <channel>
+    <title>Lab blog</title>
+    <item>
+      <title>Video &lt;b&gt;world&lt;/b&gt; models</title>
+      <link>https://lab.example/posts/world-models/?utm_source=rss</link>
+      <description><![CDATA[<p>We train <em>video</em> world models.</p>]]></description>
+      <pubDate>Mon, 02 Mar 2026 09:30:00 +0000</pubDate>
+      <dc:creator>Grace Hopper; Alan Turing</dc:creator>
+      <category>vision</category>
+      <prism:doi>10.1101/2026.03.01.123456</prism:doi>
+      <guid isPermaLink="false">post-41</guid>
+    </item>
+    <item>
+      <title>Cross-posted from arXiv</title>
+      <link>https://arxiv.org/abs/2602.22094v2</link>
+      <description>Agent memory.</description>
+      <guid>https://lab.example/p/42</guid>
+    </item>
+    <item>
+      <title>Only a link</title>
+      <link>https://lab.example/posts/link-only/#comments</link>
+    </item>
+    <item>
+      <title>Nothing to identify</title>
+    </item>
+  </channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>tag:venue.example,2026:paper-7</id>
+    <title type="html">Diffusion &amp;amp; planning</title>
+    <summary>Planning with diffusion.</summary>
+    <content type="html">&lt;p&gt;Full text&lt;/p&gt;</content>
+    <published>2026-03-01T12:00:00Z</published>
+    <updated>2026-03-02T12:00:00Z</updated>
+    <author><name>Ada Lovelace</name><email>ada@example.org</email></author>
+    <category term="cs.LG"/>
+    <link rel="alternate" href="https://venue.example/papers/7"/>
+    <link rel="related" type="application/pdf" href="https://venue.example/papers/7.pdf"/>
+  </entry>
+</feed>`
+
+const jsonFeedDoc = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Accepted papers",
+  "items": [
+    {
+      "id": 17,
+      "url": "https://conf.example/accepted/17",
+      "title": "Token pruning",
+      "content_html": "<p>Fewer tokens.</p>",
+      "date_published": "2026-02-20T08:00:00Z",
+      "authors": [{"name": "Edsger Dijkstra"}],
+      "tags": ["efficiency"],
+      "attachments": [{"url": "https://conf.example/17.pdf", "mime_type": "application/pdf"}]
+    }
+  ]
+}`
+
+func serveFeeds(t *testing.T) *httptest.Server {
+	feeds := map[string]string{"/rss": rssFeed, "/atom": atomFeed, "/json": jsonFeedDoc}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feeds[r.URL.Path]))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func fetchTopic(t *testing.T, q source.TopicQuery) []model.Paper {
+	t.Helper()
+	src := &topicSource{client: NewClient(httpclient.Policy{Attempts: 1})}
+	if err := src.Check(q); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	papers, err := src.Fetch(context.Background(), q)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	return papers
+}
+
+func TestFetchRSS(t *testing.T) {
+	t.Parallel()
+
+	server := serveFeeds(t)
+	papers := fetchTopic(t, source.TopicQuery{Query: server.URL + "/rss"})
+	if len(papers) != 3 {
+		t.Fatalf("expected the item without an ID to be skipped, got %d papers", len(papers))
+	}
+
+	first := papers[0]
+	if first.ID != "doi:10.1101/2026.03.01.123456" || first.DOI != "10.1101/2026.03.01.123456" {
+		t.Fatalf("expected a DOI-based ID, got %q (doi %q)", first.ID, first.DOI)
+	}
+	if first.Title != "Video world models" || first.Abstract != "We train video world models." {
+		t.Fatalf("expected markup stripped, got %q / %q", first.Title, first.Abstract)
+	}
+	if !first.PublishedAt.Equal(time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected pubDate %v", first.PublishedAt)
+	}
+	if len(first.Authors) != 2 || first.Authors[1] != "Alan Turing" || first.PrimaryCategory != "vision" {
+		t.Fatalf("unexpected metadata %#v", first)
+	}
+
+	if papers[1].ID != "2602.22094" || papers[1].Version != 2 {
+		t.Fatalf("expected an arXiv link to give the arXiv ID, got %q v%d", papers[1].ID, papers[1].Version)
+	}
+	if papers[2].ID != "feed:lab.example/posts/link-only" {
+		t.Fatalf("expected a normalized link ID, got %q", papers[2].ID)
+	}
+}
+
+func TestFetchAtomAndJSONFeed(t *testing.T) {
+	t.Parallel()
+
+	server := serveFeeds(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+	atom := fetchTopic(t, source.TopicQuery{Query: server.URL + "/atom"})
+	if len(atom) != 1 {
+		t.Fatalf("expected 1 Atom paper, got %d", len(atom))
+	}
+	if got := atom[0]; got.ID != "feed:"+host+"/tag:venue.example,2026:paper-7" || got.Title != "Diffusion & planning" ||
+		got.Abstract != "Planning with diffusion." || got.URL != "https://venue.example/papers/7" ||
+		got.PDFURL != "https://venue.example/papers/7.pdf" || len(got.Authors) != 1 || got.Authors[0] != "Ada Lovelace" ||
+		got.UpdatedAt.Day() != 2 {
+		t.Fatalf("unexpected Atom paper %#v", got)
+	}
+
+	jsonPapers := fetchTopic(t, source.TopicQuery{Query: server.URL + "/json"})
+	if len(jsonPapers) != 1 {
+		t.Fatalf("expected 1 JSON Feed paper, got %d", len(jsonPapers))
+	}
+	if got := jsonPapers[0]; got.ID != "feed:"+host+"/17" || got.Abstract != "Fewer tokens." || got.PDFURL != "https://conf.example/17.pdf" ||
+		got.Categories[0] != "efficiency" || got.Authors[0] != "Edsger Dijkstra" {
+		t.Fatalf("unexpected JSON Feed paper %#v", got)
+	}
+}
+
+func TestFetchAppliesFieldMappingAndCutoff(t *testing.T) {
+	t.Parallel()
+
+	var options source.Options
+	if err := yaml.Unmarshal([]byte("fields:\n  id: link\n  abstract: [content, summary]\n"), &options); err != nil {
+		t.Fatalf("unmarshal options: %v", err)
+	}
+	server := serveFeeds(t)
+	papers := fetchTopic(t, source.TopicQuery{Query: server.URL + "/atom", Options: options})
+	if papers[0].ID != "feed:venue.example/papers/7" || papers[0].Abstract != "Full text" {
+		t.Fatalf("expected the mapping to pick link and content, got %q / %q", papers[0].ID, papers[0].Abstract)
+	}
+
+	papers = fetchTopic(t, source.TopicQuery{Query: server.URL + "/json", Since: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)})
+	if len(papers) != 0 {
+		t.Fatalf("expected items before the cutoff to be dropped, got %#v", papers)
+	}
+}
+
+func TestCheckRejectsBadTopics(t *testing.T) {
+	t.Parallel()
+
+	src := &topicSource{client: NewClient(httpclient.Policy{})}
+	if err := src.Check(source.TopicQuery{Query: "cs.CV"}); err == nil || !strings.Contains(err.Error(), "URL") {
+		t.Fatalf("expected a non-URL query to be rejected, got %v", err)
+	}
+
+	var options source.Options
+	if err := yaml.Unmarshal([]byte("fields:\n  summary: description\n"), &options); err != nil {
+		t.Fatalf("unmarshal options: %v", err)
+	}
+	if err := src.Check(source.TopicQuery{Query: "https://lab.example/feed", Options: options}); err == nil {
+		t.Fatalf("expected an unknown paper field to be rejected")
+	}
+}
+
+func TestParseRejectsNonFeeds(t *testing.T) {
+	t.Parallel()
+
+	for _, doc := range []string{"<html><body>hi</body></html>", `{"version": "1", "items": []}`, ""} {
+		if _, err := parse([]byte(doc)); err == nil {
+			t.Fatalf("expected %q to be rejected", doc)
+		}
+	}
+}
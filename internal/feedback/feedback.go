@@ -0,0 +1,301 @@
+// Package feedback records up/down judgments of digested papers and learns
+// keyword weight changes and new keyword candidates from them.
+package feedback
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/scoring"
+	"github.com/kyc001/paper-radar/internal/state"
+)
+
+const (
+	// DefaultMinLabels is how many labelled papers a topic needs before
+	// tune proposes anything for it.
+	DefaultMinLabels = 3
+	// DefaultMaxCandidates caps the new keywords proposed per topic.
+	DefaultMaxCandidates = 5
+
+	// minCandidateSupport is how many up-voted papers a candidate keyword
+	// must appear in.
+	minCandidateSupport = 2
+	// Weight changes are bounded to this factor per tune, so one run of
+	// feedback cannot swing a weight arbitrarily far.
+	maxWeightFactor = 2.0
+)
+
+// Record stores label for the paper with the given ID, replacing an earlier
+// judgment of the same paper. The paper is looked up in the digest history
+// first, then in the pending queue; id may be any form model.CanonicalID
+// understands, e.g. the bare arXiv ID or the abs URL, with or without version.
+func Record(st *state.FileState, id, label string, now time.Time) (model.Feedback, error) {
+	if label != model.FeedbackUp && label != model.FeedbackDown {
+		return model.Feedback{}, fmt.Errorf("label must be %q or %q, got %q", model.FeedbackUp, model.FeedbackDown, label)
+	}
+
+	paper, ok := findPaper(st, id)
+	if !ok {
+		return model.Feedback{}, fmt.Errorf("paper %q not found in digest history or pending queue", id)
+	}
+
+	record := model.Feedback{
+		ID:       paper.Paper.ID,
+		Title:    paper.Paper.Title,
+		Abstract: paper.Paper.Abstract,
+		Topics:   paper.Topics,
+		Label:    label,
+		At:       now.UTC(),
+	}
+	for i, existing := range st.Feedback {
+		if existing.ID == record.ID {
+			st.Feedback[i] = record
+			return record, nil
+		}
+	}
+	st.Feedback = append(st.Feedback, record)
+	return record, nil
+}
+
+func findPaper(st *state.FileState, id string) (model.ScoredPaper, bool) {
+	want, _ := model.CanonicalID(id)
+	// Newest digest entries win if a paper was digested more than once.
+	for i := len(st.Digested) - 1; i >= 0; i-- {
+		if st.Digested[i].Paper.ID == want {
+			return st.Digested[i], true
+		}
+	}
+	for _, paper := range st.Pending {
+		if paper.Paper.ID == want {
+			return paper, true
+		}
+	}
+	return model.ScoredPaper{}, false
+}
+
+// Change is one proposed keyword edit. Old is 0 for a new candidate. Up and
+// Down count the labelled papers of the topic the keyword matches.
+type Change struct {
+	Word string
+	Old  int
+	New  int
+	Up   int
+	Down int
+}
+
+// Proposal is tune's suggestion for one topic.
+type Proposal struct {
+	Topic   string
+	Up      int
+	Down    int
+	Changes []Change
+}
+
+// Options tune the tuner; zero values fall back to the defaults.
+type Options struct {
+	MinLabels     int
+	MaxCandidates int
+}
+
+// Tune proposes keyword weight changes and new keywords for every topic
+// with enough labelled papers. A keyword's weight is scaled by how much more
+// often it matches up-voted than down-voted papers (Laplace-smoothed, capped
+// at maxWeightFactor either way). Candidates are words and phrases common
+// among up-voted papers that are rare among down-voted ones and in the
+// rest of the digest history (background), so generic vocabulary drops out.
+func Tune(topics []config.Topic, labels []model.Feedback, background []model.ScoredPaper, opts Options) []Proposal {
+	minLabels := opts.MinLabels
+	if minLabels <= 0 {
+		minLabels = DefaultMinLabels
+	}
+	maxCandidates := opts.MaxCandidates
+	if maxCandidates <= 0 {
+		maxCandidates = DefaultMaxCandidates
+	}
+
+	// Labelled papers are judged on their own; keeping them in the
+	// background would count every candidate against itself.
+	labelled := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		labelled[label.ID] = true
+	}
+	var unlabelled []model.ScoredPaper
+	for _, paper := range background {
+		if !labelled[paper.Paper.ID] {
+			unlabelled = append(unlabelled, paper)
+		}
+	}
+
+	var proposals []Proposal
+	for _, topic := range topics {
+		// Replace-mode topics score only seed similarity; keywords are unused.
+		if topic.SeedMode == config.SeedReplace {
+			continue
+		}
+
+		var up, down []model.Feedback
+		for _, label := range labels {
+			if !contains(label.Topics, topic.Name) {
+				continue
+			}
+			if label.Label == model.FeedbackUp {
+				up = append(up, label)
+			} else {
+				down = append(down, label)
+			}
+		}
+		if len(up)+len(down) < minLabels {
+			continue
+		}
+
+		upCounters := counters(up, topic.Match)
+		downCounters := counters(down, topic.Match)
+
+		proposal := Proposal{Topic: topic.Name, Up: len(up), Down: len(down)}
+		for _, keyword := range topic.Keywords {
+			u, d := matching(upCounters, keyword.Word), matching(downCounters, keyword.Word)
+			if u+d == 0 {
+				continue
+			}
+			lift := (float64(u+1) / float64(len(up)+2)) / (float64(d+1) / float64(len(down)+2))
+			lift = math.Max(1/maxWeightFactor, math.Min(maxWeightFactor, lift))
+			weight := int(math.Max(1, math.Round(float64(keyword.Weight)*lift)))
+			if weight != keyword.Weight {
+				proposal.Changes = append(proposal.Changes, Change{Word: keyword.Word, Old: keyword.Weight, New: weight, Up: u, Down: d})
+			}
+		}
+
+		proposal.Changes = append(proposal.Changes, candidates(topic, up, upCounters, downCounters, unlabelled, maxCandidates)...)
+		if len(proposal.Changes) > 0 {
+			proposals = append(proposals, proposal)
+		}
+	}
+	return proposals
+}
+
+func candidates(topic config.Topic, up []model.Feedback, upCounters, downCounters []func(string) int, background []model.ScoredPaper, limit int) []Change {
+	if len(up) < minCandidateSupport {
+		return nil
+	}
+
+	var known []string
+	for _, keyword := range append(append([]config.Keyword(nil), topic.Keywords...), topic.ExcludeKeywords...) {
+		known = append(known, strings.ToLower(keyword.Word))
+	}
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, label := range up {
+		for _, term := range scoring.CandidateTerms(label.Title + " " + label.Abstract) {
+			if !seen[term] && !overlaps(term, known) {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	backgroundCounters := make([]func(string) int, 0, len(background))
+	for _, paper := range background {
+		backgroundCounters = append(backgroundCounters, scoring.KeywordCounter(paper.Paper, topic.Match))
+	}
+
+	type scored struct {
+		change Change
+		score  float64
+	}
+	var ranked []scored
+	for _, term := range terms {
+		u := matching(upCounters, term)
+		if u < minCandidateSupport {
+			continue
+		}
+		d := matching(downCounters, term)
+		upRate := float64(u) / float64(len(upCounters))
+		noise := rate(d, len(downCounters))
+		if bg := rate(matching(backgroundCounters, term), len(backgroundCounters)); bg > noise {
+			noise = bg
+		}
+		if upRate < 2*noise {
+			continue
+		}
+		ranked = append(ranked, scored{change: Change{Word: term, New: 1, Up: u, Down: d}, score: upRate - noise})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		if ranked[i].change.Up != ranked[j].change.Up {
+			return ranked[i].change.Up > ranked[j].change.Up
+		}
+		// Prefer the phrase over its words when they are equally good.
+		if wi, wj := strings.Count(ranked[i].change.Word, " "), strings.Count(ranked[j].change.Word, " "); wi != wj {
+			return wi > wj
+		}
+		return ranked[i].change.Word < ranked[j].change.Word
+	})
+
+	var changes []Change
+	for _, r := range ranked {
+		if len(changes) == limit {
+			break
+		}
+		if overlaps(r.change.Word, known) {
+			continue
+		}
+		changes = append(changes, r.change)
+		known = append(known, r.change.Word)
+	}
+	return changes
+}
+
+func counters(labels []model.Feedback, match string) []func(string) int {
+	out := make([]func(string) int, 0, len(labels))
+	for _, label := range labels {
+		out = append(out, scoring.KeywordCounter(model.Paper{Title: label.Title, Abstract: label.Abstract}, match))
+	}
+	return out
+}
+
+// matching counts the documents word occurs in.
+func matching(counters []func(string) int, word string) int {
+	n := 0
+	for _, count := range counters {
+		if count(word) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// overlaps reports whether term is already covered by, or covers, one of
+// the topic's keywords.
+func overlaps(term string, known []string) bool {
+	for _, word := range known {
+		if strings.Contains(term, word) || strings.Contains(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
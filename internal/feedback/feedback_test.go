@@ -0,0 +1,98 @@
+package feedback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/state"
+)
+
+func TestRecordFindsPaperByAnyIDFormAndReplacesLabel(t *testing.T) {
+	t.Parallel()
+
+	st := state.FileState{
+		Digested: []model.ScoredPaper{{
+			Paper:  model.Paper{ID: "2602.23153", Version: 2, Title: "Streaming 4D", Abstract: "video", URL: "http://arxiv.org/abs/2602.23153v2"},
+			Topics: []string{"4D"},
+		}},
+	}
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := Record(&st, "2602.23153", model.FeedbackUp, now); err != nil {
+		t.Fatalf("record by bare ID: %v", err)
+	}
+	record, err := Record(&st, "http://arxiv.org/abs/2602.23153v1", model.FeedbackDown, now)
+	if err != nil {
+		t.Fatalf("record by abs URL: %v", err)
+	}
+	if len(st.Feedback) != 1 || st.Feedback[0].Label != model.FeedbackDown {
+		t.Fatalf("expected the second label to replace the first, got %#v", st.Feedback)
+	}
+	if record.Title != "Streaming 4D" || len(record.Topics) != 1 || record.Topics[0] != "4D" {
+		t.Fatalf("record should keep the paper text and topics, got %#v", record)
+	}
+
+	if _, err := Record(&st, "2602.00000", model.FeedbackUp, now); err == nil {
+		t.Fatalf("expected an error for an unknown paper")
+	}
+	if _, err := Record(&st, "2602.23153", "meh", now); err == nil {
+		t.Fatalf("expected an error for an unknown label")
+	}
+}
+
+func TestTuneAdjustsWeightsAndProposesCandidates(t *testing.T) {
+	t.Parallel()
+
+	topic := config.Topic{
+		Name:     "Video",
+		Match:    config.MatchToken,
+		Keywords: []config.Keyword{{Word: "video", Weight: 2}, {Word: "segmentation", Weight: 2}, {Word: "audio", Weight: 1}},
+	}
+	label := func(label, title, abstract string) model.Feedback {
+		return model.Feedback{Title: title, Abstract: abstract, Topics: []string{"Video"}, Label: label}
+	}
+	labels := []model.Feedback{
+		label(model.FeedbackUp, "Streaming video generation", "Gaussian splatting for long video"),
+		label(model.FeedbackUp, "Video world models", "Gaussian splatting with memory"),
+		label(model.FeedbackUp, "Camera control for video", "A method based on gaussian splatting"),
+		label(model.FeedbackDown, "Medical video segmentation", "A method for lesion segmentation"),
+		label(model.FeedbackDown, "Surgical segmentation", "A method for organ segmentation"),
+	}
+	background := []model.ScoredPaper{
+		{Paper: model.Paper{Title: "A method", Abstract: "Another method"}},
+		{Paper: model.Paper{Title: "Gaussian splatting survey", Abstract: "method"}},
+	}
+
+	proposals := Tune([]config.Topic{topic}, labels, background, Options{})
+	if len(proposals) != 1 {
+		t.Fatalf("expected one proposal, got %#v", proposals)
+	}
+	changes := map[string]Change{}
+	for _, change := range proposals[0].Changes {
+		changes[change.Word] = change
+	}
+
+	// video: in 3/3 up and 1/2 down -> lift (4/5)/(2/4) = 1.6 -> weight 3
+	if got := changes["video"]; got.Old != 2 || got.New != 3 {
+		t.Fatalf("expected video 2 -> 3, got %#v", got)
+	}
+	// segmentation: in 0/3 up and 2/2 down -> lift capped at 0.5 -> weight 1
+	if got := changes["segmentation"]; got.Old != 2 || got.New != 1 {
+		t.Fatalf("expected segmentation 2 -> 1, got %#v", got)
+	}
+	if _, ok := changes["audio"]; ok {
+		t.Fatalf("audio matches no labelled paper and should be left alone")
+	}
+	if got, ok := changes["gaussian splatting"]; !ok || got.Old != 0 || got.Up != 3 {
+		t.Fatalf("expected gaussian splatting as a candidate, got %#v", proposals[0].Changes)
+	}
+	if _, ok := changes["method"]; ok {
+		t.Fatalf("method is common in down-voted and background papers and should not be proposed")
+	}
+
+	if got := Tune([]config.Topic{topic}, labels[:2], background, Options{}); len(got) != 0 {
+		t.Fatalf("expected no proposals below min labels, got %#v", got)
+	}
+}
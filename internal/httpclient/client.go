@@ -0,0 +1,235 @@
+// Package httpclient is the HTTP layer shared by paper-radar's sources and
+// notifiers. It retries network errors, 429 and 5xx responses with
+// exponential backoff and jitter, honouring Retry-After; requests that are
+// not idempotent are only retried on 429.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAttempts is how many times a request is tried, including the
+	// first, when Policy.Attempts is unset.
+	DefaultAttempts = 3
+	// DefaultTimeout bounds a single attempt when Policy.Timeout is unset.
+	DefaultTimeout = 20 * time.Second
+	// DefaultMaxPerHost is the per-host limit when Policy.MaxPerHost is unset.
+	DefaultMaxPerHost = 2
+
+	defaultBaseDelay = time.Second
+	defaultMaxDelay  = 30 * time.Second
+)
+
+// Policy controls retries for one source. Zero fields take the defaults.
+type Policy struct {
+	// Attempts is the total number of tries, including the first.
+	Attempts int
+	// Timeout bounds each attempt, not the request as a whole.
+	Timeout time.Duration
+	// BaseDelay is the backoff before the first retry; it doubles after
+	// each further failure.
+	BaseDelay time.Duration
+	// MaxDelay caps both the backoff and a server's Retry-After.
+	MaxDelay time.Duration
+	// MaxPerHost bounds the requests in flight to one host across every
+	// Client in the process, counting a response until its body is closed.
+	// When Clients disagree, the lowest limit of those that have reached
+	// the host applies to all of them.
+	MaxPerHost int
+	// Wait, when set, is called before every attempt, retries included.
+	// Sources with a rate limit hook it in here.
+	Wait func(ctx context.Context) error
+}
+
+// Client sends requests for one named source under its Policy.
+type Client struct {
+	name       string
+	policy     Policy
+	httpClient *http.Client
+	logf       func(format string, args ...any)
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// New returns a Client for source name (used in retry log lines).
+func New(name string, policy Policy) *Client {
+	if policy.Attempts <= 0 {
+		policy.Attempts = DefaultAttempts
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = DefaultTimeout
+	}
+	if policy.MaxPerHost <= 0 {
+		policy.MaxPerHost = DefaultMaxPerHost
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultMaxDelay
+	}
+	return &Client{
+		name:       name,
+		policy:     policy,
+		httpClient: &http.Client{Timeout: policy.Timeout},
+		logf:       logStderr,
+		sleep:      sleep,
+	}
+}
+
+// Do sends req, retrying transient failures. The response of the last
+// attempt is returned as is, so callers still check its status. A request
+// with a body must be replayable (http.NewRequest sets GetBody for the
+// usual readers). A request that is not idempotent, by the rules net/http
+// uses (see idempotent), is only retried on 429: after a 5xx or a network
+// error it may already have been acted on, and a webhook would post twice.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	slots := slotsFor(req.URL.Host)
+	safe := idempotent(req)
+	for attempt := 1; ; attempt++ {
+		if err := slots.acquire(ctx, c.policy.MaxPerHost); err != nil {
+			return nil, err
+		}
+		release := slots.release
+
+		resp, err := c.attempt(req)
+		if attempt >= c.policy.Attempts || !retryable(ctx, resp, err, safe) {
+			if err != nil {
+				release()
+				return nil, err
+			}
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+
+		delay := c.backoff(attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = min(after, c.policy.MaxDelay)
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		release()
+
+		c.logf("%s: attempt %d/%d failed (%s), retrying in %s", c.name, attempt, c.policy.Attempts, reason, delay.Round(time.Millisecond))
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends one try of req once the source's Wait allows it.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	if c.policy.Wait != nil {
+		if err := c.policy.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	try, err := rewind(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(try)
+}
+
+// rewind returns a copy of req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("rewind request body: %w", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// idempotent reports whether req may be sent twice: a GET, HEAD, OPTIONS
+// or TRACE, or any request whose caller opted in with an Idempotency-Key
+// or X-Idempotency-Key header. As in net/http, a nil header value opts in
+// without sending the header.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	_, key := req.Header["Idempotency-Key"]
+	_, xKey := req.Header["X-Idempotency-Key"]
+	return key || xKey
+}
+
+// retryable reports whether a failed attempt may succeed when repeated:
+// 429, and for idempotent requests also network errors and timeouts
+// (unless the caller gave up) and 5xx.
+func retryable(ctx context.Context, resp *http.Response, err error, idempotent bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return idempotent && !errors.Is(err, context.Canceled)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return idempotent && resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// backoff returns the delay before retry number attempt: BaseDelay doubled
+// per earlier failure, capped at MaxDelay, with the upper half jittered so
+// clients that failed together don't retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.policy.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.policy.MaxDelay {
+		delay = c.policy.MaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func logStderr(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
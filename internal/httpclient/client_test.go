@@ -0,0 +1,306 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status (setting
+// Retry-After when given) and answers "ok" afterwards. It also checks that
+// every attempt carries the full request body.
+func flakyServer(t *testing.T, failures int, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost && string(body) != "payload" {
+			t.Errorf("attempt %d got body %q", calls.Load()+1, body)
+		}
+		if int(calls.Add(1)) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// newTestClient returns a client that records its sleeps and log lines
+// instead of waiting.
+func newTestClient(policy Policy) (*Client, *[]time.Duration, *[]string) {
+	var delays []time.Duration
+	var logs []string
+	client := New("test", policy)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	client.logf = func(format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+	return client, &delays, &logs
+}
+
+func TestDoRetriesServerErrorsWithBackoff(t *testing.T) {
+	t.Parallel()
+
+	server, calls := flakyServer(t, 2, http.StatusServiceUnavailable, "")
+	client, delays, logs := newTestClient(Policy{Attempts: 3, BaseDelay: 100 * time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte("payload")))
+	req.Header["Idempotency-Key"] = nil
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("expected success on the third attempt, got %s after %d calls", resp.Status, calls.Load())
+	}
+	if len(*delays) != 2 {
+		t.Fatalf("expected 2 backoffs, got %v", *delays)
+	}
+	// 100ms then 200ms, each jittered into its upper half.
+	if d := (*delays)[0]; d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Fatalf("first backoff out of range: %v", d)
+	}
+	if d := (*delays)[1]; d < 100*time.Millisecond || d > 200*time.Millisecond {
+		t.Fatalf("second backoff out of range: %v", d)
+	}
+	if len(*logs) != 2 || !strings.HasPrefix((*logs)[0], "test: attempt 1/3 failed (503") {
+		t.Fatalf("expected a log line per retry, got %q", *logs)
+	}
+}
+
+func TestDoGivesUpAfterAttempts(t *testing.T) {
+	t.Parallel()
+
+	server, calls := flakyServer(t, 5, http.StatusBadGateway, "")
+	client, _, _ := newTestClient(Policy{Attempts: 2})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 2 {
+		t.Fatalf("expected the last 502 after 2 calls, got %s after %d calls", resp.Status, calls.Load())
+	}
+}
+
+func TestDoRetriesPostsOnlyWhenSafe(t *testing.T) {
+	t.Parallel()
+
+	// A 502 may come after the webhook already posted the message.
+	server, calls := flakyServer(t, 1, http.StatusBadGateway, "")
+	client, _, _ := newTestClient(Policy{Attempts: 3})
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte("payload")))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 1 {
+		t.Fatalf("expected a POST not to be retried after a 502, got %s after %d calls", resp.Status, calls.Load())
+	}
+
+	// A 429 says the request was not processed.
+	server, calls = flakyServer(t, 1, http.StatusTooManyRequests, "")
+	req, _ = http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte("payload")))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("expected a POST to be retried after a 429, got %s after %d calls", resp.Status, calls.Load())
+	}
+}
+
+func TestDoHonoursRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server, calls := flakyServer(t, 1, http.StatusTooManyRequests, "7")
+	client, delays, _ := newTestClient(Policy{MaxDelay: time.Minute})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 2 || len(*delays) != 1 || (*delays)[0] != 7*time.Second {
+		t.Fatalf("expected one 7s wait from Retry-After, got %v after %d calls", *delays, calls.Load())
+	}
+}
+
+func TestDoDoesNotRetryClientErrors(t *testing.T) {
+	t.Parallel()
+
+	server, calls := flakyServer(t, 1, http.StatusNotFound, "")
+	client, delays, _ := newTestClient(Policy{})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound || calls.Load() != 1 || len(*delays) != 0 {
+		t.Fatalf("expected a single 404 without retries, got %s after %d calls", resp.Status, calls.Load())
+	}
+}
+
+func TestDoRetriesTimeouts(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client, _, logs := newTestClient(Policy{Timeout: 50 * time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 2 || len(*logs) != 1 {
+		t.Fatalf("expected the timed-out attempt to be retried, got %d calls, logs %q", calls.Load(), *logs)
+	}
+}
+
+// peakServer answers every request after a short delay and tracks the
+// most requests it had in flight at once.
+func peakServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &peak
+}
+
+// getConcurrently sends n GETs to url through client at once.
+func getConcurrently(t *testing.T, client *Client, url string, n int) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Errorf("do: %v", err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDoLimitsRequestsPerHost(t *testing.T) {
+	t.Parallel()
+
+	server, peak := peakServer(t)
+	client, _, _ := newTestClient(Policy{MaxPerHost: 2})
+	getConcurrently(t, client, server.URL, 6)
+
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("expected at most 2 requests in flight (and reaching 2), got %d", got)
+	}
+}
+
+func TestDoAppliesTheLowestPerHostLimit(t *testing.T) {
+	t.Parallel()
+
+	server, peak := peakServer(t)
+	loose, _, _ := newTestClient(Policy{MaxPerHost: 3})
+	strict, _, _ := newTestClient(Policy{MaxPerHost: 1})
+
+	// The looser client reaches the host first; the stricter limit still
+	// wins once that client shows up.
+	getConcurrently(t, loose, server.URL, 1)
+	getConcurrently(t, strict, server.URL, 1)
+	getConcurrently(t, loose, server.URL, 6)
+
+	if got := peak.Load(); got != 1 {
+		t.Fatalf("expected the lowest limit of 1 to apply, got %d in flight", got)
+	}
+}
+
+func TestRetryAfterParsesSecondsAndDates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"120":                           2 * time.Minute,
+		"Sun, 01 Mar 2026 12:00:30 GMT": 30 * time.Second,
+		"Sun, 01 Mar 2026 11:00:00 GMT": 0,
+	}
+	for value, want := range cases {
+		if got, ok := retryAfter(value, now); !ok || got != want {
+			t.Fatalf("retryAfter(%q) = %v, %v; want %v", value, got, ok, want)
+		}
+	}
+	if _, ok := retryAfter("soon", now); ok {
+		t.Fatalf("expected an unparsable Retry-After to be ignored")
+	}
+}
+
+func TestLimiterSpacesCalls(t *testing.T) {
+	t.Parallel()
+
+	l := NewLimiter(30 * time.Millisecond)
+	begin := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected 3 calls to take at least 60ms, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = NewLimiter(time.Hour)
+	l.Wait(ctx)
+	if err := l.Wait(ctx); err == nil {
+		t.Fatalf("expected a cancelled context to stop waiting")
+	}
+}
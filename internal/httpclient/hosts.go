@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+var (
+	hostsMu sync.Mutex
+	hosts   = map[string]*hostSlots{}
+)
+
+// hostSlots bounds the requests in flight to one host. Clients may ask for
+// different limits; the lowest one any of them has asked for applies, so
+// the limit does not depend on which Client reaches the host first.
+type hostSlots struct {
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	// freed is closed, and replaced, whenever a slot frees up.
+	freed chan struct{}
+}
+
+// slotsFor returns the slots of host, creating them on first use.
+func slotsFor(host string) *hostSlots {
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	slots, ok := hosts[host]
+	if !ok {
+		slots = &hostSlots{freed: make(chan struct{})}
+		hosts[host] = slots
+	}
+	return slots
+}
+
+// acquire lowers the host's limit to limit if that is lower and blocks
+// until a slot is free or ctx is done.
+func (s *hostSlots) acquire(ctx context.Context, limit int) error {
+	for {
+		s.mu.Lock()
+		if s.limit == 0 || limit < s.limit {
+			s.limit = limit
+		}
+		if s.inFlight < s.limit {
+			s.inFlight++
+			s.mu.Unlock()
+			return nil
+		}
+		freed := s.freed
+		s.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *hostSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	close(s.freed)
+	s.freed = make(chan struct{})
+}
+
+// releasingBody frees a host slot once the caller is done with the
+// response.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
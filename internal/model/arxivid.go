@@ -0,0 +1,100 @@
+package model
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// arxivIDRe finds an arXiv identifier at the end of an ID, abs/pdf URL or
+// OAI identifier: new-style "2602.23153" or old-style "hep-th/9901001" (with
+// an optional subject class, "math.GT/0309136"), then an optional version.
+var arxivIDRe = regexp.MustCompile(`(?i)(?:^|[/:\s])([0-9]{4}\.[0-9]{4,5}|([a-z]+(?:-[a-z]+)?)(?:\.[a-z]{2})?/([0-9]{7}))(?:v([0-9]+))?(?:\.pdf)?/?$`)
+
+// ArxivID is a canonical arXiv identifier. Base never carries a version,
+// and old-style IDs drop their subject class the way arXiv itself does
+// ("math.GT/0309136" becomes "math/0309136"), so every form of one paper's
+// ID shares a Base. Version is 0 when the source did not name one.
+type ArxivID struct {
+	Base    string
+	Version int
+}
+
+// Prefixes of the IDs sources give papers that are not on arXiv. Such IDs
+// are opaque: nothing is parsed out of them, so a DOI or a feed link that
+// happens to end in digits never passes for an arXiv ID.
+const (
+	DOIPrefix  = "doi:"
+	FeedPrefix = "feed:"
+)
+
+// arxivHosts are the hosts whose URLs name arXiv papers by arXiv ID.
+var arxivHosts = []string{"arxiv.org", "papers.cool"}
+
+// ParseArxivID extracts the arXiv ID from raw, which may be a bare ID
+// ("2602.23153v2", "arXiv:2602.23153"), an arxiv.org abs or pdf URL, a
+// papers.cool URL or an OAI identifier. URLs on other hosts and IDs with a
+// non-arXiv prefix are never arXiv IDs.
+func ParseArxivID(raw string) (ArxivID, bool) {
+	raw = strings.TrimSpace(raw)
+	if !mayNameArxivPaper(raw) {
+		return ArxivID{}, false
+	}
+	m := arxivIDRe.FindStringSubmatch(raw)
+	if m == nil {
+		return ArxivID{}, false
+	}
+
+	id := ArxivID{Base: m[1]}
+	if m[2] != "" {
+		id.Base = strings.ToLower(m[2]) + "/" + m[3]
+	}
+	if m[4] != "" {
+		id.Version, _ = strconv.Atoi(m[4])
+	}
+	return id, true
+}
+
+func mayNameArxivPaper(raw string) bool {
+	lower := strings.ToLower(raw)
+	if strings.HasPrefix(lower, DOIPrefix) || strings.HasPrefix(lower, FeedPrefix) {
+		return false
+	}
+	if !strings.Contains(lower, "://") {
+		return true
+	}
+	parsed, err := url.Parse(lower)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(parsed.Hostname(), "www.")
+	for _, arxivHost := range arxivHosts {
+		if host == arxivHost || strings.HasSuffix(host, "."+arxivHost) {
+			return true
+		}
+	}
+	return false
+}
+
+func (id ArxivID) String() string {
+	return id.Base
+}
+
+// Versioned returns the ID with its version suffix, e.g. "2602.23153v2".
+func (id ArxivID) Versioned() string {
+	if id.Version == 0 {
+		return id.Base
+	}
+	return id.Base + "v" + strconv.Itoa(id.Version)
+}
+
+// CanonicalID returns the key a paper with source identifier raw is
+// deduplicated on: its arXiv base ID and version when raw names an arXiv
+// paper, raw itself (trimmed, version 0) otherwise.
+func CanonicalID(raw string) (string, int) {
+	if id, ok := ParseArxivID(raw); ok {
+		return id.Base, id.Version
+	}
+	return strings.TrimSpace(raw), 0
+}
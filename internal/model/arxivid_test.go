@@ -0,0 +1,52 @@
+package model
+
+import "testing"
+
+func TestParseArxivID(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in      string
+		base    string
+		version int
+	}{
+		{"2602.23153", "2602.23153", 0},
+		{"2602.23153v2", "2602.23153", 2},
+		{"arXiv:2602.23153v1", "2602.23153", 1},
+		{"http://arxiv.org/abs/2602.22094v1", "2602.22094", 1},
+		{"https://arxiv.org/pdf/2602.22094v3.pdf", "2602.22094", 3},
+		{"https://papers.cool/arxiv/2602.22094", "2602.22094", 0},
+		{"oai:arXiv.org:2602.22094", "2602.22094", 0},
+		{"0704.0001", "0704.0001", 0},
+		{"hep-th/9901001", "hep-th/9901001", 0},
+		{"http://arxiv.org/abs/hep-th/9901001v2", "hep-th/9901001", 2},
+		{"math.GT/0309136", "math/0309136", 0},
+	}
+	for _, tc := range cases {
+		id, ok := ParseArxivID(tc.in)
+		if !ok || id.Base != tc.base || id.Version != tc.version {
+			t.Fatalf("ParseArxivID(%q) = %#v, %v; want %s v%d", tc.in, id, ok, tc.base, tc.version)
+		}
+	}
+
+	for _, in := range []string{"", "invalid-id", "paper-1", "https://example.com/posts/12345",
+		"https://blog.example.com/posts/2024.10001", "https://lab.org/news/1234567", "feed:lab.org/news/1234567", "doi:10.1000/2024.10001"} {
+		if id, ok := ParseArxivID(in); ok {
+			t.Fatalf("ParseArxivID(%q) should fail, got %#v", in, id)
+		}
+	}
+}
+
+func TestCanonicalIDKeepsNonArxivIDs(t *testing.T) {
+	t.Parallel()
+
+	if id, version := CanonicalID(" http://arxiv.org/abs/2602.23153v4 "); id != "2602.23153" || version != 4 {
+		t.Fatalf("unexpected canonical arXiv ID %q v%d", id, version)
+	}
+	if id, version := CanonicalID(" tag:example.com,2026:post-7 "); id != "tag:example.com,2026:post-7" || version != 0 {
+		t.Fatalf("non-arXiv IDs should only be trimmed, got %q v%d", id, version)
+	}
+	if got := (ArxivID{Base: "2602.23153", Version: 2}).Versioned(); got != "2602.23153v2" {
+		t.Fatalf("unexpected versioned ID %q", got)
+	}
+}
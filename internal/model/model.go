@@ -2,17 +2,160 @@ package model
 
 import "time"
 
+// Paper is a fetched paper. ID is canonical (see CanonicalID), so the same
+// paper from different sources shares it; Version is the arXiv version the
+// source reported, if any. Abstract is the source's original abstract and
+// is what scoring runs against; AISummary holds optional machine-generated
+// enrichment (e.g. the Kimi Q&A from papers.cool) and is only rendered.
 type Paper struct {
 	ID          string    `json:"id"`
+	Version     int       `json:"version,omitempty"`
 	Title       string    `json:"title"`
-	Summary     string    `json:"summary"`
+	Abstract    string    `json:"abstract"`
+	AISummary   string    `json:"ai_summary,omitempty"`
 	URL         string    `json:"url"`
 	PublishedAt time.Time `json:"published_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Metadata as reported by the source; any of it may be empty. Comment
+	// is the authors' free-form note, often the venue and a code link.
+	// Affiliations are the authors' distinct affiliations in author order.
+	Authors         []string `json:"authors,omitempty"`
+	Affiliations    []string `json:"affiliations,omitempty"`
+	PrimaryCategory string   `json:"primary_category,omitempty"`
+	Categories      []string `json:"categories,omitempty"`
+	Comment         string   `json:"comment,omitempty"`
+	JournalRef      string   `json:"journal_ref,omitempty"`
+	DOI             string   `json:"doi,omitempty"`
+	PDFURL          string   `json:"pdf_url,omitempty"`
+
+	// Announcement is how the paper appeared in an arXiv daily listing
+	// (one of the Announce constants); empty for other sources.
+	Announcement string `json:"announcement,omitempty"`
+
+	// Citations is the citation data looked up for the paper once it was
+	// queued; nil until then or when the lookup found nothing.
+	Citations *Citations `json:"citations,omitempty"`
 }
 
+// Citations is what a Semantic Scholar-compatible API knows about a paper.
+type Citations struct {
+	Count         int    `json:"count"`
+	Influential   int    `json:"influential"`
+	Venue         string `json:"venue,omitempty"`
+	OpenAccessPDF string `json:"open_access_pdf,omitempty"`
+}
+
+// Announcement types of an arXiv daily listing: a new submission, a
+// cross-list from another category, a replacement (new version) and a
+// replacement of a cross-listed paper.
+const (
+	AnnounceNew          = "new"
+	AnnounceCross        = "cross"
+	AnnounceReplace      = "replace"
+	AnnounceReplaceCross = "replace-cross"
+)
+
+// ScoredPaper is a paper queued for the digest. Updated marks a revision of
+// a paper that was already processed in an earlier run; PreviousVersion is
+// the version seen then (0 if the source reported none). FollowedAuthors
+// and FollowedAffiliations list the authors and affiliations that matched a
+// topic's follow lists.
 type ScoredPaper struct {
-	Paper  Paper    `json:"paper"`
-	Score  int      `json:"score"`
-	Topics []string `json:"topics"`
+	Paper                Paper      `json:"paper"`
+	Score                float64    `json:"score"`
+	Topics               []string   `json:"topics"`
+	Breakdown            []ScoreHit `json:"breakdown,omitempty"`
+	Updated              bool       `json:"updated,omitempty"`
+	PreviousVersion      int        `json:"previous_version,omitempty"`
+	FollowedAuthors      []string   `json:"followed_authors,omitempty"`
+	FollowedAffiliations []string   `json:"followed_affiliations,omitempty"`
+}
+
+// SeenPaper is what state remembers about a processed paper: the latest
+// version and update time any source reported, so revisions can be told
+// apart from re-fetches. Both are zero when unknown.
+type SeenPaper struct {
+	Version   int       `json:"version,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// IsRevisedBy reports whether paper is a newer revision than the one seen:
+// a higher arXiv version or, when neither side has a version, a later
+// update time. Nothing counts as a revision of an unknown baseline.
+func (s SeenPaper) IsRevisedBy(paper Paper) bool {
+	if paper.Version > 0 && s.Version > 0 {
+		return paper.Version > s.Version
+	}
+	if paper.Version == 0 && s.Version == 0 && !s.UpdatedAt.IsZero() {
+		return paper.UpdatedAt.After(s.UpdatedAt)
+	}
+	return false
+}
+
+// Track returns s advanced to include paper's version and update time.
+func (s SeenPaper) Track(paper Paper) SeenPaper {
+	if paper.Version > s.Version {
+		s.Version = paper.Version
+	}
+	if paper.UpdatedAt.After(s.UpdatedAt) {
+		s.UpdatedAt = paper.UpdatedAt
+	}
+	return s
+}
+
+// ScoreHit is one keyword's contribution to a paper's score within one
+// field. Exclude keywords in penalty mode appear with a negative Weight.
+// Hits is after the keyword's saturation cap; FieldWeight is the topic's
+// title or abstract multiplier. Points is what the hit added to (or, when
+// negative, removed from) the score: Hits × Weight × FieldWeight for the
+// keyword scorer, the term's share of the BM25/TF-IDF score otherwise.
+type ScoreHit struct {
+	Topic       string  `json:"topic"`
+	Keyword     string  `json:"keyword"`
+	Field       string  `json:"field"`
+	Hits        int     `json:"hits"`
+	Weight      int     `json:"weight"`
+	FieldWeight int     `json:"field_weight,omitempty"`
+	Points      float64 `json:"points"`
+}
+
+// VetoRecord notes a paper that would have been queued for a topic but was
+// dropped by one of the topic's exclude keywords.
+type VetoRecord struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Topic    string    `json:"topic"`
+	Reason   string    `json:"reason"`
+	Score    float64   `json:"score"`
+	VetoedAt time.Time `json:"vetoed_at"`
+}
+
+// CorpusStats are document frequencies of scoring terms over the papers
+// fetched in one run. State keeps a rolling window of them so BM25/TF-IDF
+// topics see more than a single day's feed. Tokens is the summed token
+// length of all documents; DocFreq is keyed by scoring term.
+type CorpusStats struct {
+	At      time.Time      `json:"at"`
+	Docs    int            `json:"docs"`
+	Tokens  int            `json:"tokens"`
+	DocFreq map[string]int `json:"doc_freq"`
+}
+
+// Feedback labels.
+const (
+	FeedbackUp   = "up"
+	FeedbackDown = "down"
+)
+
+// Feedback is a user's judgment of a digested paper. It keeps the text the
+// paper was scored on, so tune can still learn from it after the paper has
+// left the queue and the digest history.
+type Feedback struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Abstract string    `json:"abstract"`
+	Topics   []string  `json:"topics"`
+	Label    string    `json:"label"`
+	At       time.Time `json:"at"`
 }
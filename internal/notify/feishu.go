@@ -9,16 +9,24 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/kyc001/paper-radar/internal/httpclient"
 )
 
 const defaultMaxChunkChars = 2800
 
+// defaultTimeout bounds one webhook attempt unless the policy sets one.
+const defaultTimeout = 10 * time.Second
+
 type FeishuWebhook struct {
-	httpClient *http.Client
+	httpClient *httpclient.Client
 }
 
-func NewFeishuWebhook() *FeishuWebhook {
-	return &FeishuWebhook{httpClient: &http.Client{Timeout: 10 * time.Second}}
+func NewFeishuWebhook(policy httpclient.Policy) *FeishuWebhook {
+	if policy.Timeout <= 0 {
+		policy.Timeout = defaultTimeout
+	}
+	return &FeishuWebhook{httpClient: httpclient.New("feishu", policy)}
 }
 
 func (f *FeishuWebhook) SendText(ctx context.Context, webhookURL, text string) error {
@@ -0,0 +1,315 @@
+// Package oaipmh harvests arXiv metadata over OAI-PMH (ListRecords with
+// the arXivRaw or arXiv metadata format), for backfilling a topic over
+// months of submissions that the search API pages through slowly and caps.
+package oaipmh
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/arxiv"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+// DefaultEndpoint is arXiv's OAI-PMH base URL.
+const DefaultEndpoint = "https://oaipmh.arxiv.org/oai"
+
+// Metadata formats the harvester understands.
+const (
+	PrefixArXivRaw = "arXivRaw"
+	PrefixArXiv    = "arXiv"
+)
+
+// errBadResumptionToken is the repository's answer to a resumption token
+// it no longer knows, typically one that expired between runs.
+var errBadResumptionToken = errors.New("oai-pmh: bad resumption token")
+
+// harvestInterval spaces requests to arXiv's OAI-PMH host, which asks
+// harvesters for the same pace as the search API.
+const harvestInterval = 3 * time.Second
+
+// harvestLimiter is shared by every Client harvesting an arXiv host other
+// than the search API's, so topics harvested concurrently still take turns.
+var harvestLimiter = httpclient.NewLimiter(harvestInterval)
+
+type Client struct {
+	httpClient *httpclient.Client
+	endpoint   string
+}
+
+// NewClient returns a client harvesting endpoint and retrying under policy.
+// arXiv throttles harvesters with 503 and Retry-After, which the retries
+// honour; unless policy says otherwise only one request is in flight at a
+// time, and every attempt at an arXiv host waits its turn on a rate limiter.
+func NewClient(endpoint string, policy httpclient.Policy) *Client {
+	if policy.MaxPerHost <= 0 {
+		policy.MaxPerHost = 1
+	}
+	if limiter := limiterFor(endpoint); limiter != nil {
+		policy.Wait = limiter.Wait
+	}
+	return &Client{httpClient: httpclient.New("oai-pmh", policy), endpoint: endpoint}
+}
+
+// limiterFor returns the rate limiter for requests to endpoint: the search
+// API's own on its host, harvestLimiter on other arXiv hosts and none
+// elsewhere.
+func limiterFor(endpoint string) *httpclient.Limiter {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return nil
+	}
+	switch host := strings.ToLower(parsed.Hostname()); {
+	case host == arxiv.APIHost:
+		return arxiv.APILimiter
+	case host == "arxiv.org" || strings.HasSuffix(host, ".arxiv.org"):
+		return harvestLimiter
+	}
+	return nil
+}
+
+// ListRecords is one ListRecords request: either a resumption token, or
+// the prefix, set and date range to start a harvest with.
+type ListRecords struct {
+	Token  string
+	Prefix string
+	Set    string
+	From   string
+	Until  string
+}
+
+func (r ListRecords) values() url.Values {
+	params := url.Values{}
+	params.Set("verb", "ListRecords")
+	if r.Token != "" {
+		params.Set("resumptionToken", r.Token)
+		return params
+	}
+	params.Set("metadataPrefix", r.Prefix)
+	for key, value := range map[string]string{"set": r.Set, "from": r.From, "until": r.Until} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	return params
+}
+
+// Page is one ListRecords response. Token is empty on the last page.
+// ResponseDate is when the repository answered, which is where the next
+// incremental harvest starts from.
+type Page struct {
+	Papers       []model.Paper
+	Token        string
+	ResponseDate time.Time
+}
+
+// listRecords fetches one page. A request that matches no records is an
+// empty last page, not an error.
+func (c *Client) listRecords(ctx context.Context, request ListRecords) (Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+request.values().Encode(), nil)
+	if err != nil {
+		return Page{}, err
+	}
+	req.Header.Set("User-Agent", "paper-radar/0.1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Page{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Page{}, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var doc oaiResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return Page{}, err
+	}
+	page := Page{ResponseDate: parseDate(doc.ResponseDate)}
+	if doc.Error != nil {
+		switch doc.Error.Code {
+		case "noRecordsMatch":
+			return page, nil
+		case "badResumptionToken":
+			return Page{}, errBadResumptionToken
+		}
+		return Page{}, fmt.Errorf("oai-pmh error %s: %s", doc.Error.Code, strings.TrimSpace(doc.Error.Message))
+	}
+
+	page.Token = strings.TrimSpace(doc.ListRecords.Token)
+	for _, record := range doc.ListRecords.Records {
+		if record.Header.Status == "deleted" {
+			continue
+		}
+		if paper, ok := record.paper(); ok {
+			page.Papers = append(page.Papers, paper)
+		}
+	}
+	return page, nil
+}
+
+type oaiResponse struct {
+	ResponseDate string    `xml:"responseDate"`
+	Error        *oaiError `xml:"error"`
+	ListRecords  struct {
+		Records []oaiRecord `xml:"record"`
+		Token   string      `xml:"resumptionToken"`
+	} `xml:"ListRecords"`
+}
+
+type oaiError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type oaiRecord struct {
+	Header struct {
+		Identifier string `xml:"identifier"`
+		Status     string `xml:"status,attr"`
+	} `xml:"header"`
+	Metadata struct {
+		Raw   *rawMetadata   `xml:"http://arxiv.org/OAI/arXivRaw/ arXivRaw"`
+		ArXiv *arxivMetadata `xml:"http://arxiv.org/OAI/arXiv/ arXiv"`
+	} `xml:"metadata"`
+}
+
+// rawMetadata is the arXivRaw format: the submitter's metadata as is, with
+// every version's submission date.
+type rawMetadata struct {
+	ID       string `xml:"id"`
+	Versions []struct {
+		Version string `xml:"version,attr"`
+		Date    string `xml:"date"`
+	} `xml:"version"`
+	Title      string `xml:"title"`
+	Authors    string `xml:"authors"`
+	Categories string `xml:"categories"`
+	Comments   string `xml:"comments"`
+	JournalRef string `xml:"journal-ref"`
+	DOI        string `xml:"doi"`
+	Abstract   string `xml:"abstract"`
+}
+
+// arxivMetadata is the arXiv format: parsed author names but no versions.
+type arxivMetadata struct {
+	ID      string `xml:"id"`
+	Created string `xml:"created"`
+	Updated string `xml:"updated"`
+	Authors []struct {
+		Keyname   string `xml:"keyname"`
+		Forenames string `xml:"forenames"`
+		Suffix    string `xml:"suffix"`
+	} `xml:"authors>author"`
+	Title      string `xml:"title"`
+	Categories string `xml:"categories"`
+	Comments   string `xml:"comments"`
+	JournalRef string `xml:"journal-ref"`
+	DOI        string `xml:"doi"`
+	Abstract   string `xml:"abstract"`
+}
+
+func (r oaiRecord) paper() (model.Paper, bool) {
+	switch {
+	case r.Metadata.Raw != nil:
+		return r.Metadata.Raw.paper()
+	case r.Metadata.ArXiv != nil:
+		return r.Metadata.ArXiv.paper()
+	}
+	return model.Paper{}, false
+}
+
+func (m rawMetadata) paper() (model.Paper, bool) {
+	id, ok := model.ParseArxivID(m.ID)
+	if !ok {
+		return model.Paper{}, false
+	}
+	paper := basePaper(id, m.Title, m.Abstract, m.Categories, m.Comments, m.JournalRef, m.DOI)
+	for i, version := range m.Versions {
+		date := parseDate(version.Date)
+		if i == 0 {
+			paper.PublishedAt = date
+		}
+		if id, ok := model.ParseArxivID(m.ID + strings.TrimSpace(version.Version)); ok && id.Version > paper.Version {
+			paper.Version = id.Version
+			paper.UpdatedAt = date
+		}
+	}
+	paper.Authors = splitAuthors(m.Authors)
+	paper.PDFURL = "https://arxiv.org/pdf/" + model.ArxivID{Base: paper.ID, Version: paper.Version}.Versioned()
+	return paper, true
+}
+
+func (m arxivMetadata) paper() (model.Paper, bool) {
+	id, ok := model.ParseArxivID(m.ID)
+	if !ok {
+		return model.Paper{}, false
+	}
+	paper := basePaper(id, m.Title, m.Abstract, m.Categories, m.Comments, m.JournalRef, m.DOI)
+	paper.PublishedAt = parseDate(m.Created)
+	paper.UpdatedAt = parseDate(m.Updated)
+	for _, author := range m.Authors {
+		name := normalizeWhitespace(author.Forenames + " " + author.Keyname + " " + author.Suffix)
+		if name != "" {
+			paper.Authors = append(paper.Authors, name)
+		}
+	}
+	paper.PDFURL = "https://arxiv.org/pdf/" + paper.ID
+	return paper, true
+}
+
+func basePaper(id model.ArxivID, title, abstract, categories, comments, journalRef, doi string) model.Paper {
+	paper := model.Paper{
+		ID:         id.Base,
+		Version:    id.Version,
+		Title:      normalizeWhitespace(title),
+		Abstract:   normalizeWhitespace(abstract),
+		URL:        "https://arxiv.org/abs/" + id.Base,
+		Categories: strings.Fields(categories),
+		Comment:    normalizeWhitespace(comments),
+		JournalRef: normalizeWhitespace(journalRef),
+		DOI:        strings.TrimSpace(doi),
+	}
+	if len(paper.Categories) > 0 {
+		paper.PrimaryCategory = paper.Categories[0]
+	}
+	return paper
+}
+
+// splitAuthors splits arXivRaw's free-text author list ("A. One, B. Two
+// and C. Three").
+func splitAuthors(authors string) []string {
+	authors = strings.ReplaceAll(normalizeWhitespace(authors), " and ", ", ")
+	var names []string
+	for _, name := range strings.Split(authors, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+var dateLayouts = []string{time.RFC3339, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 MST", "2006-01-02"}
+
+func parseDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+func normalizeWhitespace(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
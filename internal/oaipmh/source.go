@@ -0,0 +1,232 @@
+package oaipmh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/source"
+)
+
+func init() {
+	source.Register("oai-pmh", func(env source.Env) source.Source {
+		return &topicSource{policy: env.HTTP("oai-pmh"), checkpoints: env.Checkpoints}
+	})
+}
+
+// dateLayout is OAI-PMH's day granularity, the one arXiv supports.
+const dateLayout = "2006-01-02"
+
+// Options are the oai-pmh source's source_options. From defaults to the
+// topic's max_age_days cutoff on the first harvest; without either the
+// whole set is harvested.
+type Options struct {
+	Endpoint       string `yaml:"endpoint"`
+	MetadataPrefix string `yaml:"metadata_prefix"`
+	From           string `yaml:"from"`
+	Until          string `yaml:"until"`
+}
+
+// checkpoint is where a topic's harvest stands between runs. Endpoint and
+// Request are what the config asks for, so a changed config starts over. From is the
+// date the current harvest started from; while it is under way Token
+// resumes it. Once it is done, NextFrom is where the next incremental
+// harvest starts (nothing is left when the config sets Until).
+type checkpoint struct {
+	Endpoint string      `json:"endpoint"`
+	Request  ListRecords `json:"request"`
+	From     string      `json:"from,omitempty"`
+	Token    string      `json:"token,omitempty"`
+	Started  time.Time   `json:"started,omitempty"`
+	Done     bool        `json:"done,omitempty"`
+	NextFrom string      `json:"next_from,omitempty"`
+}
+
+// start returns the request that starts (or restarts) cp's harvest.
+func (cp checkpoint) start() ListRecords {
+	request := cp.Request
+	if cp.From != "" {
+		request.From = cp.From
+	}
+	return request
+}
+
+// topicSource harvests the set named by a topic's query, e.g. "cs" or
+// "cs:cs:CV".
+type topicSource struct {
+	policy      httpclient.Policy
+	checkpoints source.Checkpoints
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// client returns the client for endpoint, shared by every topic harvesting
+// it.
+func (s *topicSource) client(endpoint string) *Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients == nil {
+		s.clients = make(map[string]*Client)
+	}
+	client, ok := s.clients[endpoint]
+	if !ok {
+		client = NewClient(endpoint, s.policy)
+		s.clients[endpoint] = client
+	}
+	return client
+}
+
+func (s *topicSource) Check(q source.TopicQuery) error {
+	if q.Query == "" {
+		return fmt.Errorf("query must be the OAI-PMH set to harvest, e.g. cs:cs:CV")
+	}
+	_, _, err := options(q)
+	return err
+}
+
+// Fetch harvests whole pages until at least q.MaxResults papers are in or
+// the harvest is done, checkpointing after every page. A harvest that
+// fails part way returns the pages it got with the error, checkpointed so
+// the next run resumes after them. An expired resumption token restarts
+// the harvest once per call; a second one fails it.
+func (s *topicSource) Fetch(ctx context.Context, q source.TopicQuery) ([]model.Paper, error) {
+	endpoint, request, err := options(q)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "oai-pmh/" + q.Topic
+	fresh := func(from string) checkpoint {
+		return checkpoint{Endpoint: endpoint, Request: request, From: from}
+	}
+	cp := s.load(key)
+	if cp.Endpoint != endpoint || cp.Request != request {
+		// The first harvest reaches back to the topic's max_age_days.
+		cp = fresh("")
+		if request.From == "" && !q.Since.IsZero() {
+			cp.From = q.Since.UTC().Format(dateLayout)
+		}
+	}
+	switch {
+	case cp.Done && request.Until != "":
+		return nil, nil
+	case cp.Done:
+		cp = fresh(cp.NextFrom)
+	}
+	next := cp.start()
+	if cp.Token != "" {
+		next = ListRecords{Token: cp.Token}
+	}
+
+	client := s.client(endpoint)
+	var papers []model.Paper
+	restarted := false
+	for q.MaxResults <= 0 || len(papers) < q.MaxResults {
+		page, err := client.listRecords(ctx, next)
+		if errors.Is(err, errBadResumptionToken) && next.Token != "" && !restarted {
+			// The token expired, so start the harvest over. Papers already
+			// harvested are known as seen.
+			restarted = true
+			cp = fresh(cp.From)
+			next = cp.start()
+			continue
+		}
+		if err != nil {
+			s.save(key, cp)
+			return papers, fmt.Errorf("harvest %s: %w", describe(next), err)
+		}
+
+		papers = append(papers, page.Papers...)
+		if cp.Started.IsZero() {
+			cp.Started = page.ResponseDate
+		}
+		if page.Token == "" {
+			cp = checkpoint{Endpoint: endpoint, Request: request, Done: true, NextFrom: nextFrom(cp)}
+			break
+		}
+		cp.Token = page.Token
+		next = ListRecords{Token: page.Token}
+	}
+	s.save(key, cp)
+	return papers, nil
+}
+
+func (s *topicSource) Capabilities() source.Capabilities {
+	return source.Capabilities{DateCutoff: true}
+}
+
+func (s *topicSource) load(key string) checkpoint {
+	var cp checkpoint
+	if s.checkpoints == nil {
+		return cp
+	}
+	if value, ok := s.checkpoints.Checkpoint(key); ok {
+		// An unreadable checkpoint only costs a fresh harvest.
+		_ = json.Unmarshal([]byte(value), &cp)
+	}
+	return cp
+}
+
+func (s *topicSource) save(key string, cp checkpoint) {
+	if s.checkpoints == nil {
+		return
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return
+	}
+	s.checkpoints.SetCheckpoint(key, string(data))
+}
+
+// nextFrom is where the harvest after cp's begins: the day cp's started,
+// as records changed later that day may have been missed.
+func nextFrom(cp checkpoint) string {
+	if cp.Started.IsZero() {
+		return cp.start().From
+	}
+	return cp.Started.UTC().Format(dateLayout)
+}
+
+func options(q source.TopicQuery) (string, ListRecords, error) {
+	var opts Options
+	if err := q.Options.Decode(&opts); err != nil {
+		return "", ListRecords{}, err
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if parsed, err := url.Parse(endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", ListRecords{}, fmt.Errorf("source_options: endpoint must be an http(s) URL, got %q", endpoint)
+	}
+
+	request := ListRecords{Prefix: opts.MetadataPrefix, Set: q.Query, From: opts.From, Until: opts.Until}
+	switch request.Prefix {
+	case "":
+		request.Prefix = PrefixArXivRaw
+	case PrefixArXivRaw, PrefixArXiv:
+	default:
+		return "", ListRecords{}, fmt.Errorf("source_options: metadata_prefix must be %s or %s, got %q", PrefixArXivRaw, PrefixArXiv, request.Prefix)
+	}
+	for name, value := range map[string]string{"from": request.From, "until": request.Until} {
+		if _, err := time.Parse(dateLayout, value); value != "" && err != nil {
+			return "", ListRecords{}, fmt.Errorf("source_options: %s must be a YYYY-MM-DD date, got %q", name, value)
+		}
+	}
+	return endpoint, request, nil
+}
+
+func describe(request ListRecords) string {
+	if request.Token != "" {
+		return "from resumption token " + request.Token
+	}
+	return fmt.Sprintf("set %s from %q until %q", request.Set, request.From, request.Until)
+}
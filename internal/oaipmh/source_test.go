@@ -0,0 +1,308 @@
+package oaipmh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/arxiv"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/source"
+	"gopkg.in/yaml.v3"
+)
+
+// rawRecord renders an arXivRaw record for arXiv ID id with versions
+// v1..versions.
+func rawRecord(id string, versions int) string {
+	var versionXML strings.Builder
+	for v := 1; v <= versions; v++ {
+		fmt.Fprintf(&versionXML, `<version version="v%d"><date>Mon, %d Mar 2026 10:00:00 GMT</date></version>`, v, v+1)
+	}
+	return fmt.Sprintf(`<record><header><identifier>oai:arXiv.org:%[1]s</identifier><datestamp>2026-03-05</datestamp><setSpec>cs</setSpec></header>
+<metadata><arXivRaw xmlns="http://arxiv.org/OAI/arXivRaw/"><id>%[1]s</id>%[2]s
+<title>Paper %[1]s</title><authors>Grace Hopper, Alan Turing and Ada Lovelace</authors>
+<categories>cs.CV cs.LG</categories><comments>CVPR 2026</comments><abstract>  Video world models.  </abstract></arXivRaw></metadata></record>`, id, versionXML.String())
+}
+
+const deletedRecord = `<record><header status="deleted"><identifier>oai:arXiv.org:2603.09999</identifier><datestamp>2026-03-05</datestamp></header></record>`
+
+// harvestServer serves a three-page harvest with resumption tokens "p2"
+// and "p3". failing names tokens to answer with 500; expired ones get
+// badResumptionToken.
+type harvestServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+	failing  map[string]bool
+	expired  map[string]bool
+}
+
+func newHarvestServer(t *testing.T) *harvestServer {
+	pages := map[string]struct {
+		records string
+		next    string
+	}{
+		"":   {rawRecord("2603.00001", 1) + deletedRecord + rawRecord("2603.00002", 2), "p2"},
+		"p2": {rawRecord("2603.00003", 1) + rawRecord("2603.00004", 1), "p3"},
+		"p3": {rawRecord("2603.00005", 3), ""},
+	}
+	h := &harvestServer{failing: map[string]bool{}, expired: map[string]bool{}}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("resumptionToken")
+		h.mu.Lock()
+		h.requests = append(h.requests, r.URL.RawQuery)
+		failing, expired := h.failing[token], h.expired[token]
+		h.mu.Unlock()
+
+		if failing {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"><responseDate>2026-03-06T04:00:00Z</responseDate>`)
+		if expired {
+			fmt.Fprint(w, `<error code="badResumptionToken">expired</error></OAI-PMH>`)
+			return
+		}
+		page := pages[token]
+		fmt.Fprintf(w, `<ListRecords>%s<resumptionToken cursor="0">%s</resumptionToken></ListRecords></OAI-PMH>`, page.records, page.next)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func (h *harvestServer) fail(token string, failing bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failing[token] = failing
+}
+
+func (h *harvestServer) expire(token string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expired[token] = true
+}
+
+func (h *harvestServer) log() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.requests...)
+}
+
+type memoryCheckpoints map[string]string
+
+func (m memoryCheckpoints) Checkpoint(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func (m memoryCheckpoints) SetCheckpoint(key, value string) {
+	if value == "" {
+		delete(m, key)
+		return
+	}
+	m[key] = value
+}
+
+func harvestQuery(t *testing.T, endpoint, options string, maxResults int) source.TopicQuery {
+	t.Helper()
+	var opts source.Options
+	if err := yaml.Unmarshal([]byte("endpoint: "+endpoint+"\n"+options), &opts); err != nil {
+		t.Fatalf("unmarshal options: %v", err)
+	}
+	return source.TopicQuery{Topic: "Backfill", Query: "cs:cs:CV", MaxResults: maxResults, Options: opts}
+}
+
+func ids(t *testing.T, src *topicSource, q source.TopicQuery) []string {
+	t.Helper()
+	papers, err := src.Fetch(context.Background(), q)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	var got []string
+	for _, paper := range papers {
+		got = append(got, paper.ID)
+	}
+	return got
+}
+
+func TestHarvestFollowsResumptionTokens(t *testing.T) {
+	t.Parallel()
+
+	server := newHarvestServer(t)
+	checkpoints := memoryCheckpoints{}
+	src := &topicSource{policy: httpclient.Policy{Attempts: 1}, checkpoints: checkpoints}
+	q := harvestQuery(t, server.URL, "from: 2025-09-01\nuntil: 2026-03-01\n", 0)
+	if err := src.Check(q); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+
+	papers, err := src.Fetch(context.Background(), q)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(papers) != 5 {
+		t.Fatalf("expected 5 papers over 3 pages without the deleted record, got %d", len(papers))
+	}
+	requests := server.log()
+	if len(requests) != 3 || !strings.Contains(requests[0], "metadataPrefix=arXivRaw") || !strings.Contains(requests[0], "set=cs%3Acs%3ACV") ||
+		!strings.Contains(requests[0], "from=2025-09-01") || !strings.Contains(requests[0], "until=2026-03-01") || requests[2] != "resumptionToken=p3&verb=ListRecords" {
+		t.Fatalf("unexpected requests %q", requests)
+	}
+
+	second := papers[1]
+	if second.ID != "2603.00002" || second.Version != 2 || second.PublishedAt.Day() != 2 || second.UpdatedAt.Day() != 3 {
+		t.Fatalf("unexpected versions/dates %#v", second)
+	}
+	if len(second.Authors) != 3 || second.Authors[2] != "Ada Lovelace" || second.PrimaryCategory != "cs.CV" ||
+		second.Abstract != "Video world models." || second.PDFURL != "https://arxiv.org/pdf/2603.00002v2" {
+		t.Fatalf("unexpected metadata %#v", second)
+	}
+
+	// The backfill is done; with until set there is nothing left to do.
+	if got := ids(t, src, q); len(got) != 0 || len(server.log()) != 3 {
+		t.Fatalf("expected a finished backfill not to harvest again, got %v", got)
+	}
+}
+
+func TestHarvestResumesFromCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	server := newHarvestServer(t)
+	checkpoints := memoryCheckpoints{}
+	src := &topicSource{policy: httpclient.Policy{Attempts: 1}, checkpoints: checkpoints}
+	q := harvestQuery(t, server.URL, "", 0)
+	q.Since = time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC)
+
+	// The second page fails: the first page's papers come back with the
+	// error and the checkpoint points at the failed page.
+	server.fail("p2", true)
+	papers, err := src.Fetch(context.Background(), q)
+	if err == nil || len(papers) != 2 {
+		t.Fatalf("expected the first page and an error, got %d papers, %v", len(papers), err)
+	}
+	if !strings.Contains(checkpoints["oai-pmh/Backfill"], `"token":"p2"`) {
+		t.Fatalf("expected a checkpoint at p2, got %s", checkpoints["oai-pmh/Backfill"])
+	}
+
+	server.fail("p2", false)
+	q.Since = q.Since.AddDate(0, 0, 1) // a day later, the cutoff has moved on
+	if got := ids(t, src, q); len(got) != 3 || got[0] != "2603.00003" {
+		t.Fatalf("expected the harvest to resume at p2, got %v", got)
+	}
+	requests := server.log()
+	if !strings.Contains(requests[0], "from=2025-09-01") || requests[2] != "resumptionToken=p2&verb=ListRecords" {
+		t.Fatalf("unexpected requests %q", requests)
+	}
+
+	// Without until, the next run harvests incrementally from the day the
+	// finished harvest started.
+	ids(t, src, q)
+	if last := server.log()[len(server.log())-3]; !strings.Contains(last, "from=2026-03-06") {
+		t.Fatalf("expected an incremental harvest from 2026-03-06, got %q", last)
+	}
+}
+
+func TestHarvestRestartsOnExpiredToken(t *testing.T) {
+	t.Parallel()
+
+	server := newHarvestServer(t)
+	checkpoints := memoryCheckpoints{}
+	src := &topicSource{policy: httpclient.Policy{Attempts: 1}, checkpoints: checkpoints}
+	q := harvestQuery(t, server.URL, "from: 2025-09-01\n", 2)
+
+	if got := ids(t, src, q); len(got) != 2 {
+		t.Fatalf("expected max_results to stop after the first page, got %v", got)
+	}
+	server.expire("p2")
+	if got := ids(t, src, q); len(got) != 2 || got[0] != "2603.00001" {
+		t.Fatalf("expected the harvest to start over, got %v", got)
+	}
+	if requests := server.log(); len(requests) != 3 || !strings.Contains(requests[2], "from=2025-09-01") {
+		t.Fatalf("unexpected requests %q", requests)
+	}
+}
+
+func TestHarvestRestartsOnlyOncePerFetch(t *testing.T) {
+	t.Parallel()
+
+	server := newHarvestServer(t)
+	server.expire("p2")
+	checkpoints := memoryCheckpoints{}
+	src := &topicSource{policy: httpclient.Policy{Attempts: 1}, checkpoints: checkpoints}
+
+	// The repository rejects the very token it issues; without a bound the
+	// harvest would start over forever.
+	papers, err := src.Fetch(context.Background(), harvestQuery(t, server.URL, "from: 2025-09-01\n", 0))
+	if err == nil || !strings.Contains(err.Error(), "bad resumption token") || len(papers) != 4 {
+		t.Fatalf("expected the first page twice and an error, got %d papers, %v", len(papers), err)
+	}
+	if requests := server.log(); len(requests) != 4 {
+		t.Fatalf("expected one restart, got requests %q", requests)
+	}
+	if !strings.Contains(checkpoints["oai-pmh/Backfill"], `"token":"p2"`) {
+		t.Fatalf("expected the checkpoint to be saved, got %s", checkpoints["oai-pmh/Backfill"])
+	}
+}
+
+func TestParsesArXivFormat(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"><responseDate>2026-03-06T04:00:00Z</responseDate><ListRecords>
+<record><header><identifier>oai:arXiv.org:hep-th/9901001</identifier></header><metadata>
+<arXiv xmlns="http://arxiv.org/OAI/arXiv/"><id>hep-th/9901001</id><created>1999-01-04</created><updated>1999-02-01</updated>
+<authors><author><keyname>Maldacena</keyname><forenames>Juan</forenames></author><author><keyname>Witten</keyname><forenames>E.</forenames><suffix>Jr</suffix></author></authors>
+<title>Large N</title><categories>hep-th</categories><doi>10.1000/xyz</doi><abstract>Holography.</abstract></arXiv></metadata></record>
+<resumptionToken/></ListRecords></OAI-PMH>`)
+	}))
+	defer server.Close()
+
+	src := &topicSource{policy: httpclient.Policy{Attempts: 1}}
+	papers, err := src.Fetch(context.Background(), harvestQuery(t, server.URL, "metadata_prefix: arXiv\n", 0))
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(papers) != 1 {
+		t.Fatalf("expected 1 paper, got %d", len(papers))
+	}
+	if got := papers[0]; got.ID != "hep-th/9901001" || got.Authors[1] != "E. Witten Jr" || got.PublishedAt.Year() != 1999 || got.DOI != "10.1000/xyz" {
+		t.Fatalf("unexpected paper %#v", got)
+	}
+}
+
+func TestCheckRejectsBadOptions(t *testing.T) {
+	t.Parallel()
+
+	src := &topicSource{}
+	for _, options := range []string{"metadata_prefix: oai_dc\n", "from: 2025/09/01\n", "set: cs\n"} {
+		if err := src.Check(harvestQuery(t, "https://oai.example/oai", options, 0)); err == nil {
+			t.Fatalf("expected %q to be rejected", options)
+		}
+	}
+	if err := src.Check(source.TopicQuery{}); err == nil {
+		t.Fatalf("expected a topic without a set to be rejected")
+	}
+}
+
+func TestLimiterForSpacesArxivHosts(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]*httpclient.Limiter{
+		DefaultEndpoint:                    harvestLimiter,
+		"https://export.arxiv.org/oai2":    arxiv.APILimiter,
+		"https://EXPORT.arxiv.org:443/oai": arxiv.APILimiter,
+		"https://oai.example/oai":          nil,
+		"https://notarxiv.org/oai":         nil,
+	}
+	for endpoint, want := range cases {
+		if got := limiterFor(endpoint); got != want {
+			t.Fatalf("%s: unexpected limiter %p, want %p", endpoint, got, want)
+		}
+	}
+}
@@ -11,79 +11,121 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kyc001/paper-radar/internal/cache"
+	"github.com/kyc001/paper-radar/internal/httpclient"
 	"github.com/kyc001/paper-radar/internal/model"
 )
 
 const defaultBaseURL = "https://papers.cool"
 
+// kimiCacheKind is the enrichment cache kind of Kimi summaries.
+const kimiCacheKind = "kimi"
+
 type Client struct {
-	httpClient *http.Client
+	httpClient *httpclient.Client
 	baseURL    string
+	cache      *cache.Cache
 }
 
-func NewClient() *Client {
+func NewClient(policy httpclient.Policy) *Client {
 	return &Client{
-		httpClient: &http.Client{Timeout: 20 * time.Second},
+		httpClient: httpclient.New("paperscool", policy),
 		baseURL:    defaultBaseURL,
 	}
 }
 
-func (c *Client) Fetch(ctx context.Context, topicQuery string, maxResults int, withKimi bool) ([]model.Paper, error) {
-	feedURL := c.resolveFeedURL(topicQuery)
+// Fetch returns up to maxResults papers from the feed topicQuery names.
+// Kimi summaries are not fetched here; see FetchKimiSummary.
+func (c *Client) Fetch(ctx context.Context, topicQuery string, maxResults int) ([]model.Paper, error) {
+	feed, err := c.fetchFeed(ctx, c.resolveFeedURL(topicQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	limit := maxResults
+	if limit <= 0 || limit > len(feed.Entries) {
+		limit = len(feed.Entries)
+	}
+
+	papers := make([]model.Paper, 0, limit)
+	for i := 0; i < limit; i++ {
+		entry := feed.Entries[i]
+		arxivID, isArxiv := model.ParseArxivID(entry.ID)
+		id, version := model.CanonicalID(entry.ID)
+		primary, categories := entry.categories()
+		papers = append(papers, model.Paper{
+			ID:              id,
+			Version:         version,
+			Title:           normalizeWhitespace(entry.Title),
+			Abstract:        normalizeWhitespace(entry.Summary),
+			URL:             entry.URL(),
+			PublishedAt:     parseTime(entry.Published),
+			UpdatedAt:       parseTime(entry.Updated),
+			Authors:         entry.authors(),
+			PrimaryCategory: primary,
+			Categories:      categories,
+			Comment:         normalizeWhitespace(entry.Comment),
+			JournalRef:      normalizeWhitespace(entry.JournalRef),
+			DOI:             strings.TrimSpace(entry.DOI),
+			PDFURL:          entry.pdfURL(arxivID, isArxiv),
+		})
+	}
 
+	return papers, nil
+}
+
+// fetchFeed downloads and decodes the Atom feed at feedURL.
+func (c *Client) fetchFeed(ctx context.Context, feedURL string) (atomFeed, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
 	if err != nil {
-		return nil, err
+		return atomFeed{}, err
 	}
 	req.Header.Set("User-Agent", "paper-radar/0.2.0")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return atomFeed{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		return atomFeed{}, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
 	}
 
 	var feed atomFeed
 	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
-		return nil, err
-	}
-
-	limit := maxResults
-	if limit <= 0 || limit > len(feed.Entries) {
-		limit = len(feed.Entries)
+		return atomFeed{}, err
 	}
+	return feed, nil
+}
 
-	papers := make([]model.Paper, 0, limit)
-	for i := 0; i < limit; i++ {
-		entry := feed.Entries[i]
-		paperID := extractPaperID(entry.ID)
-		summary := normalizeWhitespace(entry.Summary)
-		if withKimi && paperID != "" {
-			if kimi, err := c.FetchKimiSummary(ctx, paperID); err == nil && strings.TrimSpace(kimi) != "" {
-				summary = kimi
-			}
-		}
+// UseCache makes FetchKimiSummary read through enrichments, keyed by
+// canonical paper ID.
+func (c *Client) UseCache(enrichments *cache.Cache) {
+	c.cache = enrichments
+}
 
-		papers = append(papers, model.Paper{
-			ID:          strings.TrimSpace(entry.ID),
-			Title:       normalizeWhitespace(entry.Title),
-			Summary:     summary,
-			URL:         entry.URL(),
-			PublishedAt: parseTime(entry.Published),
-			UpdatedAt:   parseTime(entry.Updated),
-		})
+// FetchKimiSummary fetches and converts a Kimi summary for the given arXiv
+// paper ID, serving it from the cache when one is set and holds it.
+func (c *Client) FetchKimiSummary(ctx context.Context, paperID string) (string, error) {
+	id, _ := model.CanonicalID(paperID)
+	if summary, ok := c.cache.Get(kimiCacheKind, id); ok {
+		return summary, nil
 	}
 
-	return papers, nil
+	summary, err := c.fetchKimiSummary(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if summary != "" {
+		// A cache that cannot be written only costs a download next time.
+		_ = c.cache.Put(kimiCacheKind, id, summary)
+	}
+	return summary, nil
 }
 
-// FetchKimiSummary fetches and converts a Kimi summary for the given arXiv paper ID.
-func (c *Client) FetchKimiSummary(ctx context.Context, paperID string) (string, error) {
+func (c *Client) fetchKimiSummary(ctx context.Context, paperID string) (string, error) {
 	endpoint := fmt.Sprintf("%s/arxiv/kimi?paper=%s", c.baseURL, url.QueryEscape(paperID))
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -139,18 +181,85 @@ type atomFeed struct {
 	Entries []atomEntry `xml:"entry"`
 }
 
+// Elements in the http://arxiv.org/schemas/atom namespace are arXiv's
+// extensions (arxiv:comment etc.); papers.cool feeds may omit them.
 type atomEntry struct {
-	ID        string     `xml:"id"`
-	Title     string     `xml:"title"`
-	Summary   string     `xml:"summary"`
-	Published string     `xml:"published"`
-	Updated   string     `xml:"updated"`
-	Links     []atomLink `xml:"link"`
+	ID              string         `xml:"id"`
+	Title           string         `xml:"title"`
+	Summary         string         `xml:"summary"`
+	Published       string         `xml:"published"`
+	Updated         string         `xml:"updated"`
+	Links           []atomLink     `xml:"link"`
+	Authors         []atomAuthor   `xml:"author"`
+	PrimaryCategory atomCategory   `xml:"http://arxiv.org/schemas/atom primary_category"`
+	Categories      []atomCategory `xml:"category"`
+	Comment         string         `xml:"http://arxiv.org/schemas/atom comment"`
+	JournalRef      string         `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	DOI             string         `xml:"http://arxiv.org/schemas/atom doi"`
 }
 
 type atomLink struct {
-	Rel  string `xml:"rel,attr"`
-	Href string `xml:"href,attr"`
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func (e atomEntry) authors() []string {
+	var names []string
+	for _, author := range e.Authors {
+		if name := normalizeWhitespace(author.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// categories returns the entry's category terms, primary first.
+func (e atomEntry) categories() (string, []string) {
+	primary := strings.TrimSpace(e.PrimaryCategory.Term)
+	var terms []string
+	seen := map[string]bool{}
+	for _, term := range append([]string{primary}, categoryTerms(e.Categories)...) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	if primary == "" && len(terms) > 0 {
+		primary = terms[0]
+	}
+	return primary, terms
+}
+
+func categoryTerms(categories []atomCategory) []string {
+	terms := make([]string, 0, len(categories))
+	for _, category := range categories {
+		terms = append(terms, strings.TrimSpace(category.Term))
+	}
+	return terms
+}
+
+// pdfURL returns the entry's PDF link, falling back to arXiv's PDF URL for
+// the given ID.
+func (e atomEntry) pdfURL(id model.ArxivID, isArxiv bool) string {
+	for _, link := range e.Links {
+		if link.Href != "" && (link.Title == "pdf" || link.Type == "application/pdf") {
+			return link.Href
+		}
+	}
+	if isArxiv {
+		return "https://arxiv.org/pdf/" + id.Versioned()
+	}
+	return ""
 }
 
 func (e atomEntry) URL() string {
@@ -182,7 +291,6 @@ func normalizeWhitespace(value string) string {
 }
 
 var (
-	arxivIDRe      = regexp.MustCompile(`([0-9]{4}\.[0-9]{4,5})(v[0-9]+)?`)
 	faqQRe         = regexp.MustCompile(`<p\s+class="faq-q">\s*<strong>(Q\d+)</strong>\s*[:：]\s*(.*?)\s*</p>`)
 	faqAOpenRe     = regexp.MustCompile(`<div\s+class="faq-a">\s*`)
 	faqACloseRe    = regexp.MustCompile(`\s*</div>`)
@@ -246,11 +354,3 @@ func stripHTML(input string) string {
 	text = regexp.MustCompile(`\s+`).ReplaceAllString(text, " ")
 	return strings.TrimSpace(text)
 }
-
-func extractPaperID(entryID string) string {
-	m := arxivIDRe.FindStringSubmatch(entryID)
-	if len(m) >= 2 {
-		return m[1]
-	}
-	return ""
-}
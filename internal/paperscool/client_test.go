@@ -1,9 +1,17 @@
 package paperscool
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kyc001/paper-radar/internal/cache"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+)
 
 func TestResolveFeedURL(t *testing.T) {
-	c := NewClient()
+	c := NewClient(httpclient.Policy{})
 
 	cases := []struct {
 		in   string
@@ -22,27 +30,85 @@ func TestResolveFeedURL(t *testing.T) {
 	}
 }
 
-func TestExtractPaperID(t *testing.T) {
-	cases := []struct {
-		in   string
-		want string
-	}{
-		{"https://papers.cool/arxiv/2602.22094", "2602.22094"},
-		{"http://arxiv.org/abs/2602.22094v1", "2602.22094"},
-		{"invalid-id", ""},
+func TestStripHTML(t *testing.T) {
+	in := `<p><strong>Q1</strong>: 测试 &amp; 验证</p><div>内容&nbsp;A</div>`
+	got := stripHTML(in)
+	if got == "" || got == in {
+		t.Fatalf("stripHTML should return cleaned text, got: %q", got)
 	}
+}
 
-	for _, tc := range cases {
-		if got := extractPaperID(tc.in); got != tc.want {
-			t.Fatalf("extractPaperID(%q) = %q, want %q", tc.in, got, tc.want)
+func TestFetchParsesAuthorsAndCategories(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>https://papers.cool/arxiv/2602.22094</id>
+    <title>Agent Memory</title>
+    <summary>Long-horizon agents.</summary>
+    <author><name>Grace Hopper</name></author>
+    <author><name>Edsger Dijkstra</name></author>
+    <category term="cs.AI"/>
+    <category term="cs.CL"/>
+    <link href="https://papers.cool/arxiv/2602.22094" rel="alternate"/>
+  </entry>
+</feed>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/arxiv/cs.AI/feed" {
+			t.Errorf("unexpected path %q", r.URL.Path)
 		}
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	c := NewClient(httpclient.Policy{})
+	c.baseURL = server.URL
+
+	papers, err := c.Fetch(context.Background(), "cs.AI", 10)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(papers) != 1 {
+		t.Fatalf("expected 1 paper, got %d", len(papers))
+	}
+	paper := papers[0]
+	if paper.ID != "2602.22094" || len(paper.Authors) != 2 || paper.Authors[1] != "Edsger Dijkstra" {
+		t.Fatalf("unexpected paper %#v", paper)
+	}
+	if paper.PrimaryCategory != "cs.AI" || len(paper.Categories) != 2 {
+		t.Fatalf("expected first category as primary, got %q / %q", paper.PrimaryCategory, paper.Categories)
+	}
+	if paper.PDFURL != "https://arxiv.org/pdf/2602.22094" {
+		t.Fatalf("expected arXiv pdf fallback, got %q", paper.PDFURL)
 	}
 }
 
-func TestStripHTML(t *testing.T) {
-	in := `<p><strong>Q1</strong>: 测试 &amp; 验证</p><div>内容&nbsp;A</div>`
-	got := stripHTML(in)
-	if got == "" || got == in {
-		t.Fatalf("stripHTML should return cleaned text, got: %q", got)
+func TestFetchKimiSummaryReadsThroughCache(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.URL.Query().Get("paper"); got != "2602.22094" {
+			t.Errorf("expected the canonical ID, got %q", got)
+		}
+		w.Write([]byte("<p>Q1: What problem does it solve?</p>"))
+	}))
+	defer server.Close()
+
+	c := NewClient(httpclient.Policy{})
+	c.baseURL = server.URL
+	c.UseCache(cache.Open(t.TempDir(), cache.Options{}))
+
+	for _, id := range []string{"2602.22094v2", "2602.22094"} {
+		summary, err := c.FetchKimiSummary(context.Background(), id)
+		if err != nil {
+			t.Fatalf("fetch kimi %s: %v", id, err)
+		}
+		if summary == "" {
+			t.Fatalf("expected a summary for %s", id)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected the second lookup to be served from the cache, got %d requests", calls)
 	}
 }
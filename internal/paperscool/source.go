@@ -0,0 +1,35 @@
+package paperscool
+
+import (
+	"context"
+
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/source"
+)
+
+func init() {
+	source.Register("paperscool", func(env source.Env) source.Source {
+		client := NewClient(env.HTTP("paperscool"))
+		client.UseCache(env.Cache)
+		return &topicSource{client: client}
+	})
+}
+
+// topicSource fetches topics from papers.cool feeds. The query is a
+// channel ("cs.CV"), a feed path or a full feed URL; an empty query reads
+// the cs.AI channel.
+type topicSource struct {
+	client *Client
+}
+
+func (s *topicSource) Check(q source.TopicQuery) error {
+	return source.NoOptions(q)
+}
+
+func (s *topicSource) Fetch(ctx context.Context, q source.TopicQuery) ([]model.Paper, error) {
+	return s.client.Fetch(ctx, q.Query, q.MaxResults)
+}
+
+func (s *topicSource) Capabilities() source.Capabilities {
+	return source.Capabilities{KimiSummary: true}
+}
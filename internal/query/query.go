@@ -0,0 +1,373 @@
+// Package query implements the boolean keyword expressions topics use to
+// gate papers, e.g.
+//
+//	training-free AND (video OR 4d) AND NOT segmentation
+//
+// Operators are AND, OR and NOT (upper case); juxtaposed terms are joined
+// with an implicit AND. Terms are bare words or "quoted phrases" and may be
+// restricted to one field with a prefix such as title: or abstract:, which
+// also applies to a parenthesised group (title:(video OR 4d)).
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Field names the part of a paper a term is matched against.
+type Field string
+
+const (
+	FieldAny      Field = ""
+	FieldTitle    Field = "title"
+	FieldAbstract Field = "abstract"
+)
+
+var fieldAliases = map[string]Field{
+	"title":    FieldTitle,
+	"abstract": FieldAbstract,
+	"summary":  FieldAbstract,
+}
+
+// Matcher reports whether text occurs in the given field of the paper being
+// evaluated. FieldAny means title or abstract.
+type Matcher func(field Field, text string) bool
+
+// Expr is a parsed query expression.
+type Expr interface {
+	Eval(match Matcher) bool
+	String() string
+}
+
+// Term matches a single word or quoted phrase.
+type Term struct {
+	Field  Field
+	Text   string
+	Phrase bool
+}
+
+func (t Term) Eval(match Matcher) bool {
+	return match(t.Field, t.Text)
+}
+
+func (t Term) String() string {
+	text := t.Text
+	if t.Phrase {
+		text = fmt.Sprintf("%q", text)
+	}
+	if t.Field != FieldAny {
+		return string(t.Field) + ":" + text
+	}
+	return text
+}
+
+// And matches when every operand matches.
+type And []Expr
+
+func (a And) Eval(match Matcher) bool {
+	for _, expr := range a {
+		if !expr.Eval(match) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a And) String() string { return joinExprs([]Expr(a), " AND ") }
+
+// Or matches when any operand matches.
+type Or []Expr
+
+func (o Or) Eval(match Matcher) bool {
+	for _, expr := range o {
+		if expr.Eval(match) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Or) String() string { return joinExprs([]Expr(o), " OR ") }
+
+// Not inverts its operand.
+type Not struct {
+	X Expr
+}
+
+func (n Not) Eval(match Matcher) bool {
+	return !n.X.Eval(match)
+}
+
+func (n Not) String() string { return "NOT " + n.X.String() }
+
+func joinExprs(exprs []Expr, sep string) string {
+	parts := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		parts = append(parts, expr.String())
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// SyntaxError reports where in the source a query failed to parse. Line and
+// Column are 1-based and count runes.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Parse parses a query expression.
+func Parse(src string) (Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, p.errorf(p.peek(), "empty query")
+	}
+
+	expr, err := p.parseOr(FieldAny)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokPhrase
+	tokField
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	line   int
+	column int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokPhrase:
+		return fmt.Sprintf("phrase %q", t.text)
+	case tokField:
+		return fmt.Sprintf("field %q", t.text+":")
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+func lex(src string) ([]token, error) {
+	runes := []rune(src)
+	tokens := make([]token, 0)
+	line, column := 1, 1
+
+	advance := func(n int) {
+		for _, r := range runes[:n] {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		runes = runes[n:]
+	}
+
+	for len(runes) > 0 {
+		r := runes[0]
+		switch {
+		case unicode.IsSpace(r):
+			advance(1)
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", line: line, column: column})
+			advance(1)
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", line: line, column: column})
+			advance(1)
+		case r == '"':
+			end := 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &SyntaxError{Line: line, Column: column, Msg: "unterminated quoted phrase"}
+			}
+			text := strings.Join(strings.Fields(string(runes[1:end])), " ")
+			if text == "" {
+				return nil, &SyntaxError{Line: line, Column: column, Msg: "empty quoted phrase"}
+			}
+			tokens = append(tokens, token{kind: tokPhrase, text: text, line: line, column: column})
+			advance(end + 1)
+		default:
+			end := 0
+			for end < len(runes) && !isDelimiter(runes[end]) {
+				end++
+			}
+			word := string(runes[:end])
+			tok := token{kind: tokWord, text: word, line: line, column: column}
+			switch word {
+			case "AND":
+				tok.kind = tokAnd
+			case "OR":
+				tok.kind = tokOr
+			case "NOT":
+				tok.kind = tokNot
+			default:
+				if idx := strings.Index(word, ":"); idx > 0 {
+					// "title:video" lexes as a field prefix followed by the rest.
+					tok.kind = tokField
+					tok.text = strings.ToLower(word[:idx])
+					end = len([]rune(word[:idx])) + 1
+				}
+			}
+			tokens = append(tokens, tok)
+			advance(end)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, line: line, column: column})
+	return tokens, nil
+}
+
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &SyntaxError{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr(field Field) (Expr, error) {
+	first, err := p.parseAnd(field)
+	if err != nil {
+		return nil, err
+	}
+
+	operands := Or{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		operand, err := p.parseAnd(field)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return operands, nil
+}
+
+func (p *parser) parseAnd(field Field) (Expr, error) {
+	first, err := p.parseUnary(field)
+	if err != nil {
+		return nil, err
+	}
+
+	operands := And{first}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokPhrase, tokField, tokNot, tokLParen:
+			// implicit AND between juxtaposed terms
+		default:
+			if len(operands) == 1 {
+				return first, nil
+			}
+			return operands, nil
+		}
+
+		operand, err := p.parseUnary(field)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+}
+
+func (p *parser) parseUnary(field Field) (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		operand, err := p.parseUnary(field)
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: operand}, nil
+	}
+	return p.parsePrimary(field)
+}
+
+func (p *parser) parsePrimary(field Field) (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokWord:
+		return Term{Field: field, Text: tok.text}, nil
+	case tokPhrase:
+		return Term{Field: field, Text: tok.text, Phrase: true}, nil
+	case tokField:
+		if field != FieldAny {
+			return nil, p.errorf(tok, "nested field prefix %s", tok)
+		}
+		resolved, ok := fieldAliases[tok.text]
+		if !ok {
+			return nil, p.errorf(tok, "unknown %s (want title: or abstract:)", tok)
+		}
+		switch p.peek().kind {
+		case tokWord, tokPhrase, tokLParen:
+			return p.parsePrimary(resolved)
+		default:
+			return nil, p.errorf(p.peek(), "expected term after %s, got %s", tok, p.peek())
+		}
+	case tokLParen:
+		expr, err := p.parseOr(field)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected \")\" to close \"(\" at line %d, column %d, got %s", tok.line, tok.column, closing)
+		}
+		return expr, nil
+	default:
+		return nil, p.errorf(tok, "expected term, got %s", tok)
+	}
+}
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func matcherFor(title, abstract string) Matcher {
+	title = strings.ToLower(title)
+	abstract = strings.ToLower(abstract)
+	return func(field Field, text string) bool {
+		text = strings.ToLower(text)
+		switch field {
+		case FieldTitle:
+			return strings.Contains(title, text)
+		case FieldAbstract:
+			return strings.Contains(abstract, text)
+		default:
+			return strings.Contains(title, text) || strings.Contains(abstract, text)
+		}
+	}
+}
+
+func TestParseAndEval(t *testing.T) {
+	cases := []struct {
+		query    string
+		title    string
+		abstract string
+		want     bool
+	}{
+		{"training-free AND (video OR 4d) AND NOT segmentation", "Training-free video editing", "", true},
+		{"training-free AND (video OR 4d) AND NOT segmentation", "Training-free 4D segmentation", "", false},
+		{"training-free (video OR 4d)", "training-free", "4d scenes", true},
+		{`"long video" OR streaming`, "long-form video", "", false},
+		{`"long   video"`, "", "a long video model", true},
+		{"title:video", "", "video", false},
+		{"abstract:video", "", "video", true},
+		{"title:(video OR 4d) NOT abstract:medical", "4D avatars", "medical imaging", false},
+		{"NOT NOT agent", "agent", "", true},
+	}
+
+	for _, tc := range cases {
+		expr, err := Parse(tc.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.query, err)
+		}
+		if got := expr.Eval(matcherFor(tc.title, tc.abstract)); got != tc.want {
+			t.Fatalf("%q on title=%q abstract=%q = %v, want %v (parsed %s)", tc.query, tc.title, tc.abstract, got, tc.want, expr)
+		}
+	}
+}
+
+func TestParseReportsLineAndColumn(t *testing.T) {
+	cases := []struct {
+		query  string
+		line   int
+		column int
+	}{
+		{"video AND", 1, 10},
+		{"(video OR 4d", 1, 13},
+		{"video\n  AND venue:cvpr", 2, 7},
+		{`video "open`, 1, 7},
+		{"video )", 1, 7},
+		{"", 1, 1},
+	}
+
+	for _, tc := range cases {
+		_, err := Parse(tc.query)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("Parse(%q) error = %v, want SyntaxError", tc.query, err)
+		}
+		if syntaxErr.Line != tc.line || syntaxErr.Column != tc.column {
+			t.Fatalf("Parse(%q) error at %d:%d, want %d:%d (%v)", tc.query, syntaxErr.Line, syntaxErr.Column, tc.line, tc.column, err)
+		}
+	}
+}
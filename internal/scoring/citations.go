@@ -0,0 +1,40 @@
+package scoring
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+// CitationHits returns the breakdown hits of topic's citation rules that
+// citations match. Each adds its bonus once.
+func CitationHits(topic config.Topic, citations model.Citations) []model.ScoreHit {
+	var hits []model.ScoreHit
+	for _, rule := range topic.CitationRules {
+		var matched bool
+		var label string
+		switch rule.Field {
+		case config.CitationCount:
+			matched, label = citations.Count >= rule.Min, fmt.Sprintf("citations >= %d", rule.Min)
+		case config.CitationInfluential:
+			matched, label = citations.Influential >= rule.Min, fmt.Sprintf("influential citations >= %d", rule.Min)
+		case config.CitationVenue:
+			matched, label = strings.Contains(strings.ToLower(citations.Venue), strings.ToLower(rule.Match)), "venue "+rule.Match
+		case config.CitationOpenAccess:
+			matched, label = citations.OpenAccessPDF != "", "open-access pdf"
+		}
+		if matched {
+			hits = append(hits, model.ScoreHit{
+				Topic:   topic.Name,
+				Keyword: label,
+				Field:   "citations",
+				Hits:    1,
+				Weight:  rule.Bonus,
+				Points:  float64(rule.Bonus),
+			})
+		}
+	}
+	return hits
+}
@@ -0,0 +1,163 @@
+package scoring
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/query"
+)
+
+// BM25 parameters; the usual defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Corpus is the run-wide context topics rank against: keyword document
+// frequencies over the papers fetched in the current run plus the rolling
+// history kept in state, and each seeded topic's seed set.
+type Corpus struct {
+	docs    int
+	avgLen  float64
+	docFreq map[string]int
+	seeds   map[string]*SeedSet
+}
+
+// NewCorpus merges per-run corpus stats into one corpus.
+func NewCorpus(stats ...model.CorpusStats) *Corpus {
+	corpus := &Corpus{docFreq: make(map[string]int), seeds: make(map[string]*SeedSet)}
+	tokens := 0
+	for _, s := range stats {
+		corpus.docs += s.Docs
+		tokens += s.Tokens
+		for term, df := range s.DocFreq {
+			corpus.docFreq[term] += df
+		}
+	}
+	if corpus.docs > 0 {
+		corpus.avgLen = float64(tokens) / float64(corpus.docs)
+	}
+	return corpus
+}
+
+// SetSeeds attaches the seed set a topic's similarity score is measured
+// against.
+func (c *Corpus) SetSeeds(topic string, set *SeedSet) {
+	c.seeds[topic] = set
+}
+
+func (c *Corpus) seedSet(topic string) *SeedSet {
+	if c == nil {
+		return nil
+	}
+	return c.seeds[topic]
+}
+
+// CollectStats computes, over papers, the document frequency of every
+// keyword of every topic under that topic's match mode. Papers should be
+// unique; callers dedup by ID first.
+func CollectStats(papers []model.Paper, topics []config.Topic) model.CorpusStats {
+	stats := model.CorpusStats{
+		At:      time.Now().UTC(),
+		Docs:    len(papers),
+		DocFreq: make(map[string]int),
+	}
+
+	modes := make(map[string][]string)
+	for _, topic := range topics {
+		for _, keyword := range topic.Keywords {
+			modes[topic.Match] = append(modes[topic.Match], keyword.Word)
+		}
+	}
+
+	for _, paper := range papers {
+		stats.Tokens += documentLength(paper)
+		for mode, words := range modes {
+			doc := newDocument(paper, mode)
+			counted := make(map[string]bool, len(words))
+			for _, word := range words {
+				term := termKey(mode, word)
+				if counted[term] {
+					continue
+				}
+				counted[term] = true
+				if doc.count(query.FieldAny, word) > 0 {
+					stats.DocFreq[term]++
+				}
+			}
+		}
+	}
+
+	return stats
+}
+
+// rankHits sets Points on the hits of one keyword from its BM25 or TF-IDF
+// score, split across fields in proportion to their field-weighted hits.
+// The keyword weight multiplies the term score.
+func rankHits(doc *document, corpus *Corpus, scorer string, hits []model.ScoreHit) {
+	if len(hits) == 0 {
+		return
+	}
+	if corpus == nil {
+		corpus = NewCorpus()
+	}
+
+	tf := 0.0
+	for _, hit := range hits {
+		tf += float64(hit.Hits * hit.FieldWeight)
+	}
+
+	term := termKey(doc.mode, hits[0].Keyword)
+	docs := corpus.docs
+	df := corpus.docFreq[term]
+	if df < 1 {
+		// The paper being scored contains the term, so it occurs at least once.
+		df = 1
+	}
+	if docs < df {
+		docs = df
+	}
+
+	var termScore float64
+	switch scorer {
+	case config.ScorerBM25:
+		idf := math.Log(1 + (float64(docs-df)+0.5)/(float64(df)+0.5))
+		norm := bm25K1
+		if corpus.avgLen > 0 {
+			norm = bm25K1 * (1 - bm25B + bm25B*float64(doc.length)/corpus.avgLen)
+		}
+		termScore = idf * tf * (bm25K1 + 1) / (tf + norm)
+	default:
+		// Sublinear TF so long abstracts repeating a word don't dominate.
+		idf := math.Log(float64(1+docs)/float64(1+df)) + 1
+		termScore = (1 + math.Log(tf)) * idf
+	}
+
+	total := termScore * float64(hits[0].Weight)
+	for i := range hits {
+		hits[i].Points = total * float64(hits[i].Hits*hits[i].FieldWeight) / tf
+	}
+}
+
+// termKey identifies a keyword as counted under a match mode, so stats for
+// "Videos" in stem mode and "video" in stem mode share one entry.
+func termKey(mode, word string) string {
+	switch mode {
+	case config.MatchToken:
+		return mode + ":" + strings.Join(tokenize(word), " ")
+	case config.MatchStem:
+		return mode + ":" + strings.Join(stemTokens(tokenize(word)), " ")
+	default:
+		if mode == "" {
+			mode = config.MatchSubstring
+		}
+		return mode + ":" + strings.TrimSpace(strings.ToLower(word))
+	}
+}
+
+func documentLength(paper model.Paper) int {
+	return len(tokenize(paper.Title)) + len(tokenize(paper.Abstract))
+}
@@ -0,0 +1,79 @@
+package scoring
+
+import (
+	"strings"
+
+	"github.com/kyc001/paper-radar/internal/config"
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/query"
+)
+
+// document is a paper's title and abstract prepared for one match mode, so
+// keywords, exclude keywords and the filter expression all agree on what
+// counts as a hit.
+type document struct {
+	mode     string
+	length   int
+	title    string
+	abstract string
+	content  string
+
+	titleTokens    []string
+	abstractTokens []string
+}
+
+func newDocument(paper model.Paper, mode string) *document {
+	doc := &document{mode: mode, length: documentLength(paper)}
+	switch mode {
+	case config.MatchToken:
+		doc.titleTokens = tokenize(paper.Title)
+		doc.abstractTokens = tokenize(paper.Abstract)
+	case config.MatchStem:
+		doc.titleTokens = stemTokens(tokenize(paper.Title))
+		doc.abstractTokens = stemTokens(tokenize(paper.Abstract))
+	default:
+		doc.title = strings.ToLower(paper.Title)
+		doc.abstract = strings.ToLower(paper.Abstract)
+		doc.content = doc.title + " " + doc.abstract
+	}
+	return doc
+}
+
+// count returns how often phrase occurs in field. Substring mode counts raw
+// substrings; token and stem modes count whole-token runs.
+func (d *document) count(field query.Field, phrase string) int {
+	switch d.mode {
+	case config.MatchToken, config.MatchStem:
+		words := tokenize(phrase)
+		if d.mode == config.MatchStem {
+			words = stemTokens(words)
+		}
+		switch field {
+		case query.FieldTitle:
+			return countPhrase(d.titleTokens, words)
+		case query.FieldAbstract:
+			return countPhrase(d.abstractTokens, words)
+		default:
+			return countPhrase(d.titleTokens, words) + countPhrase(d.abstractTokens, words)
+		}
+	default:
+		word := strings.TrimSpace(strings.ToLower(phrase))
+		if word == "" {
+			return 0
+		}
+		switch field {
+		case query.FieldTitle:
+			return strings.Count(d.title, word)
+		case query.FieldAbstract:
+			return strings.Count(d.abstract, word)
+		default:
+			return strings.Count(d.content, word)
+		}
+	}
+}
+
+func (d *document) matcher() query.Matcher {
+	return func(field query.Field, text string) bool {
+		return d.count(field, text) > 0
+	}
+}
@@ -0,0 +1,95 @@
+package scoring
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/kyc001/paper-radar/internal/config"
+)
+
+// accentFolds maps accented Latin letters to their base letters. It covers
+// Latin-1 and Latin Extended-A, which is what arXiv author names use in
+// practice; combining marks are dropped separately.
+var accentFolds = func() map[rune]string {
+	groups := map[string]string{
+		"a": "àáâãäåāăą", "ae": "æ", "c": "çćĉċč", "d": "ðďđ", "e": "èéêëēĕėęě",
+		"g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ", "l": "ĺļľŀł",
+		"n": "ñńņň", "o": "òóôõöøōŏő", "oe": "œ", "r": "ŕŗř", "s": "śŝşš", "ss": "ß",
+		"t": "ţťŧ", "th": "þ", "u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ", "z": "źżž",
+	}
+	folds := make(map[rune]string)
+	for base, letters := range groups {
+		for _, r := range letters {
+			folds[r] = base
+		}
+	}
+	return folds
+}()
+
+// foldName reduces a person's name to a comparison key: lower-cased,
+// accents removed, punctuation treated as spaces. "Zoë Müller-Lüdenscheidt"
+// and "zoe muller ludenscheidt" fold alike.
+func foldName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent of a decomposed letter
+		case accentFolds[r] != "":
+			b.WriteString(accentFolds[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// followedAuthors returns the paper authors (as the paper spells them)
+// that match a followed author's name or one of its variants, along with
+// the followed names they matched.
+func followedAuthors(authors []string, follows []config.FollowAuthor) (matched []string, names []string) {
+	if len(follows) == 0 {
+		return nil, nil
+	}
+
+	keys := make(map[string]string)
+	for _, follow := range follows {
+		for _, spelling := range append([]string{follow.Name}, follow.Variants...) {
+			if key := foldName(spelling); key != "" {
+				keys[key] = follow.Name
+			}
+		}
+	}
+
+	for _, author := range authors {
+		if name, ok := keys[foldName(author)]; ok {
+			matched = append(matched, author)
+			names = append(names, name)
+		}
+	}
+	return matched, names
+}
+
+// followedAffiliations returns the paper's affiliations that contain a
+// followed affiliation as whole words, folded like names, along with the
+// followed affiliations they matched: "FAIR" follows "Meta AI (FAIR)",
+// "Max Planck" follows "Max-Planck-Institut für Informatik".
+func followedAffiliations(affiliations, follows []string) (matched []string, names []string) {
+	if len(follows) == 0 {
+		return nil, nil
+	}
+
+	for _, affiliation := range affiliations {
+		folded := " " + foldName(affiliation) + " "
+		for _, follow := range follows {
+			if key := foldName(follow); key != "" && strings.Contains(folded, " "+key+" ") {
+				matched = append(matched, affiliation)
+				names = append(names, follow)
+				break
+			}
+		}
+	}
+	return matched, names
+}
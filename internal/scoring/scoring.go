@@ -1,36 +1,173 @@
 package scoring
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/kyc001/paper-radar/internal/config"
 	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/query"
 )
 
-func ScorePaper(paper model.Paper, keywords []string) int {
-	content := paper.Title + " " + paper.Summary
-	return ScoreText(content, keywords)
+// Result is the outcome of scoring a paper against one topic. FilteredOut
+// is set when the topic's filter expression rejected the paper, in which
+// case no keywords were scored. Veto is set when an exclude keyword dropped
+// the paper in veto mode; Score then holds the keyword score the paper
+// would otherwise have had. Hits explains Score per keyword and field.
+// Followed and FollowedAffiliations list the paper's authors and
+// affiliations that are on the topic's follow lists.
+type Result struct {
+	Score                float64
+	Hits                 []model.ScoreHit
+	FilteredOut          bool
+	Veto                 string
+	Followed             []string
+	FollowedAffiliations []string
 }
 
-func ScoreText(text string, keywords []string) int {
-	if len(keywords) == 0 {
-		return 0
+// Follows reports whether a followed author or affiliation matched.
+func (r Result) Follows() bool {
+	return len(r.Followed) > 0 || len(r.FollowedAffiliations) > 0
+}
+
+// ScoreTopic gates a paper on the topic's filter expression, scores it
+// against the topic's keywords and applies its exclude keywords, either as
+// a hard veto or as a score penalty. All matching follows topic.Match.
+// BM25 and TF-IDF topics rank against corpus; a nil corpus is treated as
+// empty, which degrades to a length-normalized keyword score. Topics with
+// seeds in corpus add (or, in replace mode, score only) seed_weight × the
+// cosine similarity to their nearest seed. Papers by a followed author or
+// affiliation gain follow_bonus points in bonus mode and skip the filter in
+// bypass mode (which also queues them whatever their score).
+func ScoreTopic(paper model.Paper, topic config.Topic, corpus *Corpus) Result {
+	followed, names := followedAuthors(paper.Authors, topic.FollowAuthors)
+	labs, labNames := followedAffiliations(paper.Affiliations, topic.FollowAffiliations)
+	result := Result{Followed: followed, FollowedAffiliations: labs}
+
+	doc := newDocument(paper, topic.Match)
+	bypass := result.Follows() && topic.FollowMode == config.FollowBypass
+	if !bypass && topic.Filter.Expr != nil && !topic.Filter.Expr.Eval(doc.matcher()) {
+		return Result{FilteredOut: true}
+	}
+
+	if result.Follows() && topic.FollowMode != config.FollowBypass && topic.FollowBonus > 0 {
+		result.record([]model.ScoreHit{{
+			Topic:   topic.Name,
+			Keyword: "follow " + strings.Join(append(names, labNames...), ", "),
+			Field:   "author",
+			Hits:    len(followed) + len(labs),
+			Weight:  topic.FollowBonus,
+			Points:  float64(topic.FollowBonus),
+		}})
+	}
+	fieldWeights := map[query.Field]int{
+		query.FieldTitle:    positiveOr(topic.TitleWeight, 1),
+		query.FieldAbstract: positiveOr(topic.AbstractWeight, 1),
+	}
+	if set := corpus.seedSet(topic.Name); set != nil {
+		similarity, nearest := set.Similarity(paper)
+		if similarity > 0 {
+			weight := positiveOr(topic.SeedWeight, 1)
+			result.record([]model.ScoreHit{{
+				Topic:   topic.Name,
+				Keyword: fmt.Sprintf("seed %s (cosine %.2f)", nearest, similarity),
+				Field:   "similarity",
+				Hits:    1,
+				Weight:  weight,
+				Points:  float64(weight) * similarity,
+			}})
+		}
+	}
+
+	for _, keyword := range topic.Keywords {
+		if topic.SeedMode == config.SeedReplace {
+			break
+		}
+		maxHits := positiveOr(keyword.MaxHits, topic.MaxKeywordHits)
+		hits := collectHits(doc, topic.Name, keyword.Word, weightOf(keyword), fieldWeights, maxHits)
+		switch topic.Scorer {
+		case config.ScorerBM25, config.ScorerTFIDF:
+			rankHits(doc, corpus, topic.Scorer, hits)
+		default:
+			for i := range hits {
+				hits[i].Points = float64(hits[i].Hits * hits[i].Weight * hits[i].FieldWeight)
+			}
+		}
+		result.record(hits)
+	}
+
+	// Penalties are neither field-weighted, capped nor ranked: any mention counts.
+	for _, keyword := range topic.ExcludeKeywords {
+		if topic.ExcludeMode == config.ExcludePenalty {
+			hits := collectHits(doc, topic.Name, keyword.Word, -weightOf(keyword), nil, 0)
+			for i := range hits {
+				hits[i].Points = float64(hits[i].Hits * hits[i].Weight)
+			}
+			result.record(hits)
+			continue
+		}
+		hits := doc.count(query.FieldAny, keyword.Word)
+		if hits == 0 {
+			continue
+		}
+		result.Veto = fmt.Sprintf("exclude keyword %q matched %d time(s)", keyword.Word, hits)
+		return result
 	}
 
-	content := strings.ToLower(text)
-	total := 0
-	for _, keyword := range keywords {
-		keyword = strings.TrimSpace(strings.ToLower(keyword))
-		if keyword == "" {
+	return result
+}
+
+// collectHits counts word in the title and the abstract separately, returning
+// a hit for each field it occurs in. A positive maxHits caps the matches
+// counted across both fields, title first, so repetition in a long
+// abstract saturates instead of dominating.
+func collectHits(doc *document, topic, word string, weight int, fieldWeights map[query.Field]int, maxHits int) []model.ScoreHit {
+	var hits []model.ScoreHit
+	remaining := maxHits
+	for _, field := range []query.Field{query.FieldTitle, query.FieldAbstract} {
+		count := doc.count(field, word)
+		if maxHits > 0 {
+			count = min(count, remaining)
+			remaining -= count
+		}
+		if count == 0 {
 			continue
 		}
-		total += strings.Count(content, keyword)
+		hits = append(hits, model.ScoreHit{
+			Topic:       topic,
+			Keyword:     word,
+			Field:       string(field),
+			Hits:        count,
+			Weight:      weight,
+			FieldWeight: positiveOr(fieldWeights[field], 1),
+		})
 	}
+	return hits
+}
 
-	return total
+func (r *Result) record(hits []model.ScoreHit) {
+	for _, hit := range hits {
+		r.Score += hit.Points
+		r.Hits = append(r.Hits, hit)
+	}
 }
 
-func FilterMinScore(papers []model.ScoredPaper, minScore int) []model.ScoredPaper {
+func positiveOr(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func weightOf(keyword config.Keyword) int {
+	if keyword.Weight == 0 {
+		return 1
+	}
+	return keyword.Weight
+}
+
+func FilterMinScore(papers []model.ScoredPaper, minScore float64) []model.ScoredPaper {
 	filtered := make([]model.ScoredPaper, 0, len(papers))
 	for _, paper := range papers {
 		if paper.Score >= minScore {
@@ -42,9 +179,13 @@ func FilterMinScore(papers []model.ScoredPaper, minScore int) []model.ScoredPape
 
 func SortByScore(papers []model.ScoredPaper) {
 	sort.Slice(papers, func(i, j int) bool {
-		if papers[i].Score == papers[j].Score {
+		if papers[i].Score != papers[j].Score {
+			return papers[i].Score > papers[j].Score
+		}
+		if !papers[i].Paper.PublishedAt.Equal(papers[j].Paper.PublishedAt) {
 			return papers[i].Paper.PublishedAt.After(papers[j].Paper.PublishedAt)
 		}
-		return papers[i].Score > papers[j].Score
+		// Break ties on ID so the order never depends on input order.
+		return papers[i].Paper.ID < papers[j].Paper.ID
 	})
 }
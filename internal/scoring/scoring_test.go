@@ -1,18 +1,30 @@
 package scoring
 
 import (
+	"math"
+	"strings"
 	"testing"
 
+	"github.com/kyc001/paper-radar/internal/config"
 	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/query"
 )
 
-func TestScoreTextCountsKeywordFrequencyCaseInsensitive(t *testing.T) {
-	text := "LLM agents improve retrieval. llm systems can summarize. Agent design matters."
-	keywords := []string{"llm", "agent"}
+func TestScoreTopicCountsKeywordFrequencyCaseInsensitive(t *testing.T) {
+	paper := model.Paper{Abstract: "LLM agents improve retrieval. llm systems can summarize. Agent design matters."}
+	topic := config.Topic{Name: "A", Match: config.MatchSubstring, Keywords: []config.Keyword{{Word: "llm"}, {Word: "agent"}}}
 
-	score := ScoreText(text, keywords)
-	if score != 4 {
-		t.Fatalf("expected score 4, got %d", score)
+	if result := ScoreTopic(paper, topic, nil); result.Score != 4 {
+		t.Fatalf("expected score 4, got %v", result.Score)
+	}
+}
+
+func TestScoreTopicMultipliesByWeight(t *testing.T) {
+	paper := model.Paper{Abstract: "3D video and more 3D scenes"}
+	topic := config.Topic{Name: "A", Match: config.MatchSubstring, Keywords: []config.Keyword{{Word: "3d", Weight: 10}, {Word: "video", Weight: 2}}}
+
+	if result := ScoreTopic(paper, topic, nil); result.Score != 22 {
+		t.Fatalf("expected score 22, got %v", result.Score)
 	}
 }
 
@@ -28,3 +40,346 @@ func TestFilterMinScore(t *testing.T) {
 		t.Fatalf("expected 2 papers, got %d", len(filtered))
 	}
 }
+
+func TestScoreTopicExcludeVetoAndPenalty(t *testing.T) {
+	paper := model.Paper{Title: "3D lesion segmentation", Abstract: "medical 3D volumes for medical imaging"}
+	topic := config.Topic{
+		Keywords:        []config.Keyword{{Word: "3d", Weight: 5}},
+		ExcludeKeywords: []config.Keyword{{Word: "medical", Weight: 2}},
+		ExcludeMode:     config.ExcludeVeto,
+	}
+
+	vetoed := ScoreTopic(paper, topic, nil)
+	if vetoed.Veto == "" {
+		t.Fatalf("expected veto, got %#v", vetoed)
+	}
+	if vetoed.Score != 10 {
+		t.Fatalf("vetoed result should keep the keyword score, got %v", vetoed.Score)
+	}
+
+	topic.ExcludeMode = config.ExcludePenalty
+	penalized := ScoreTopic(paper, topic, nil)
+	if penalized.Veto != "" {
+		t.Fatalf("penalty mode should not veto, got %q", penalized.Veto)
+	}
+	if penalized.Score != 6 {
+		t.Fatalf("expected penalized score 6, got %v", penalized.Score)
+	}
+}
+
+func TestScoreTopicFilterGatesKeywordScoring(t *testing.T) {
+	expr, err := query.Parse("training-free AND (video OR 4d) AND NOT segmentation")
+	if err != nil {
+		t.Fatalf("parse filter: %v", err)
+	}
+	topic := config.Topic{
+		Filter:   config.Filter{Expr: expr},
+		Keywords: []config.Keyword{{Word: "video"}},
+	}
+
+	passed := ScoreTopic(model.Paper{Title: "Training-free video editing", Abstract: "video"}, topic, nil)
+	if passed.FilteredOut || passed.Score != 2 {
+		t.Fatalf("expected paper to pass filter with score 2, got %#v", passed)
+	}
+
+	rejected := ScoreTopic(model.Paper{Title: "Training-free video segmentation", Abstract: "video"}, topic, nil)
+	if !rejected.FilteredOut || rejected.Score != 0 {
+		t.Fatalf("expected paper to be filtered out, got %#v", rejected)
+	}
+}
+
+func TestScoreTopicFollowedAuthors(t *testing.T) {
+	expr, err := query.Parse("segmentation")
+	if err != nil {
+		t.Fatalf("parse filter: %v", err)
+	}
+	topic := config.Topic{
+		Name:          "Video",
+		Filter:        config.Filter{Expr: expr},
+		Keywords:      []config.Keyword{{Word: "video"}},
+		FollowAuthors: []config.FollowAuthor{{Name: "Zoe Muller", Variants: []string{"Z. Muller"}}},
+		FollowMode:    config.FollowBonus,
+		FollowBonus:   10,
+	}
+	paper := model.Paper{Title: "Video segmentation", Authors: []string{"A. Author", "Zoë Müller"}}
+
+	result := ScoreTopic(paper, topic, nil)
+	if len(result.Followed) != 1 || result.Followed[0] != "Zoë Müller" {
+		t.Fatalf("expected accent-insensitive match on Zoë Müller, got %#v", result.Followed)
+	}
+	// video in title (1) + follow bonus (10)
+	if result.Score != 11 {
+		t.Fatalf("expected score 11 with the follow bonus, got %v", result.Score)
+	}
+
+	paper.Authors = []string{"z. muller"}
+	if got := ScoreTopic(paper, topic, nil); len(got.Followed) != 1 {
+		t.Fatalf("expected a match on a variant spelling, got %#v", got)
+	}
+
+	topic.FollowMode = config.FollowBypass
+	if got := ScoreTopic(paper, topic, nil); got.Score != 1 || len(got.Followed) != 1 {
+		t.Fatalf("bypass mode should not add points, got %#v", got)
+	}
+
+	paper.Authors = []string{"Zoe Mueller"}
+	if got := ScoreTopic(paper, topic, nil); len(got.Followed) != 0 || got.Score != 1 {
+		t.Fatalf("expected no match for a different name, got %#v", got)
+	}
+}
+
+func TestScoreTopicFollowSkipsFilterOnlyInBypassMode(t *testing.T) {
+	expr, err := query.Parse("segmentation")
+	if err != nil {
+		t.Fatalf("parse filter: %v", err)
+	}
+	topic := config.Topic{
+		Name:          "Video",
+		Filter:        config.Filter{Expr: expr},
+		Keywords:      []config.Keyword{{Word: "video"}},
+		FollowAuthors: []config.FollowAuthor{{Name: "Zoe Muller"}},
+		FollowMode:    config.FollowBonus,
+		FollowBonus:   10,
+	}
+	paper := model.Paper{Title: "Video generation", Authors: []string{"Zoë Müller"}}
+
+	if got := ScoreTopic(paper, topic, nil); !got.FilteredOut {
+		t.Fatalf("bonus mode should keep the filter, got %#v", got)
+	}
+	topic.FollowMode = config.FollowBypass
+	if got := ScoreTopic(paper, topic, nil); got.FilteredOut || got.Score != 1 || !got.Follows() {
+		t.Fatalf("bypass mode should skip the filter, got %#v", got)
+	}
+	paper.Authors = []string{"Someone Else"}
+	if got := ScoreTopic(paper, topic, nil); !got.FilteredOut {
+		t.Fatalf("papers without a followed author should still be filtered, got %#v", got)
+	}
+}
+
+func TestScoreTopicFollowedAffiliations(t *testing.T) {
+	topic := config.Topic{
+		Name:               "Video",
+		Keywords:           []config.Keyword{{Word: "video"}},
+		FollowAffiliations: []string{"FAIR", "Max Planck"},
+		FollowMode:         config.FollowBonus,
+		FollowBonus:        5,
+	}
+	paper := model.Paper{Title: "Video generation", Affiliations: []string{"Meta AI (FAIR)", "Max-Planck-Institut für Informatik"}}
+
+	result := ScoreTopic(paper, topic, nil)
+	if len(result.FollowedAffiliations) != 2 || result.FollowedAffiliations[1] != "Max-Planck-Institut für Informatik" {
+		t.Fatalf("expected both affiliations followed, got %#v", result.FollowedAffiliations)
+	}
+	if result.Score != 6 || result.Hits[0].Keyword != "follow FAIR, Max Planck" {
+		t.Fatalf("expected one follow bonus naming both labs, got %#v", result)
+	}
+
+	paper.Affiliations = []string{"Fairfield University"}
+	if got := ScoreTopic(paper, topic, nil); got.Follows() || got.Score != 1 {
+		t.Fatalf("affiliations should match whole words only, got %#v", got)
+	}
+}
+
+func TestScoreTopicMatchModes(t *testing.T) {
+	paper := model.Paper{
+		Title:    "3DGS agents for memory-efficient videos",
+		Abstract: "A reagent-free 3D pipeline. Rendering long videos with an agent.",
+	}
+	keywords := []config.Keyword{{Word: "3d"}, {Word: "agent"}, {Word: "memory"}, {Word: "long video"}}
+
+	cases := []struct {
+		match string
+		want  float64
+	}{
+		// 3d: 3DGS + 3D, agent: agents + reagent + agent, memory: memory-efficient, long video: long videos
+		{config.MatchSubstring, 7},
+		// 3d: 3D, agent: agent
+		{config.MatchToken, 2},
+		// 3d: 3D, agent: agents + agent, long video: long videos
+		{config.MatchStem, 4},
+	}
+
+	for _, tc := range cases {
+		got := ScoreTopic(paper, config.Topic{Keywords: keywords, Match: tc.match}, nil)
+		if got.Score != tc.want {
+			t.Fatalf("match=%s expected score %v, got %v", tc.match, tc.want, got.Score)
+		}
+	}
+}
+
+func TestStemFoldsPluralsAndSuffixes(t *testing.T) {
+	groups := [][]string{
+		{"video", "videos"},
+		{"image", "images", "imaging"},
+		{"render", "rendering", "rendered"},
+		{"study", "studies"},
+		{"train", "training", "trained"},
+	}
+	for _, group := range groups {
+		want := stem(group[0])
+		for _, word := range group[1:] {
+			if got := stem(word); got != want {
+				t.Fatalf("stem(%q) = %q, want %q (stem of %q)", word, got, want, group[0])
+			}
+		}
+	}
+}
+
+func TestScoreTopicReportsHitsPerField(t *testing.T) {
+	paper := model.Paper{Title: "Video diffusion", Abstract: "A video model for video editing"}
+	topic := config.Topic{
+		Name:     "Video",
+		Keywords: []config.Keyword{{Word: "video", Weight: 2}, {Word: "diffusion"}},
+	}
+
+	result := ScoreTopic(paper, topic, nil)
+	want := []model.ScoreHit{
+		{Topic: "Video", Keyword: "video", Field: "title", Hits: 1, Weight: 2, FieldWeight: 1, Points: 2},
+		{Topic: "Video", Keyword: "video", Field: "abstract", Hits: 2, Weight: 2, FieldWeight: 1, Points: 4},
+		{Topic: "Video", Keyword: "diffusion", Field: "title", Hits: 1, Weight: 1, FieldWeight: 1, Points: 1},
+	}
+	if len(result.Hits) != len(want) {
+		t.Fatalf("expected %d hits, got %#v", len(want), result.Hits)
+	}
+	total := 0.0
+	for i, hit := range result.Hits {
+		if hit != want[i] {
+			t.Fatalf("hit[%d] = %#v, want %#v", i, hit, want[i])
+		}
+		total += hit.Points
+	}
+	if total != result.Score || result.Score != 7 {
+		t.Fatalf("breakdown total %v should equal score %v (want 7)", total, result.Score)
+	}
+}
+
+func TestScoreTopicFieldWeightsAndSaturation(t *testing.T) {
+	paper := model.Paper{
+		Title:    "Streaming video",
+		Abstract: strings.Repeat("video ", 12) + "streaming",
+	}
+	topic := config.Topic{
+		Keywords:       []config.Keyword{{Word: "video"}, {Word: "streaming", Weight: 2, MaxHits: 1}},
+		TitleWeight:    3,
+		MaxKeywordHits: 4,
+	}
+
+	// video: title 1×3 + abstract 3 (capped at 4 total); streaming: title 1×2×3, abstract capped out
+	result := ScoreTopic(paper, topic, nil)
+	if result.Score != 12 {
+		t.Fatalf("expected score 12, got %v (%#v)", result.Score, result.Hits)
+	}
+}
+
+func TestScoreTopicBM25RewardsRareTermsAndShortDocs(t *testing.T) {
+	papers := []model.Paper{
+		{ID: "1", Title: "video diffusion", Abstract: "video generation"},
+		{ID: "2", Title: "video editing", Abstract: "video"},
+		{ID: "3", Title: "video tokenizer", Abstract: "4d scenes"},
+		{ID: "4", Title: "language agents", Abstract: "planning"},
+	}
+	topic := config.Topic{
+		Name:     "Video",
+		Scorer:   config.ScorerBM25,
+		Keywords: []config.Keyword{{Word: "video"}, {Word: "4d"}},
+	}
+
+	stats := CollectStats(papers, []config.Topic{topic})
+	if stats.Docs != 4 || stats.DocFreq["substring:video"] != 3 || stats.DocFreq["substring:4d"] != 1 {
+		t.Fatalf("unexpected corpus stats: %#v", stats)
+	}
+	corpus := NewCorpus(stats)
+
+	common := ScoreTopic(model.Paper{Title: "video", Abstract: "x"}, topic, corpus)
+	rare := ScoreTopic(model.Paper{Title: "4d", Abstract: "x"}, topic, corpus)
+	if rare.Score <= common.Score {
+		t.Fatalf("rare term should outscore common term: rare=%v common=%v", rare.Score, common.Score)
+	}
+
+	short := ScoreTopic(model.Paper{Title: "4d", Abstract: "scenes"}, topic, corpus)
+	long := ScoreTopic(model.Paper{Title: "4d", Abstract: strings.Repeat("other words ", 20)}, topic, corpus)
+	if short.Score <= long.Score {
+		t.Fatalf("shorter document should score higher: short=%v long=%v", short.Score, long.Score)
+	}
+
+	total := 0.0
+	for _, hit := range short.Hits {
+		total += hit.Points
+	}
+	if math.Abs(total-short.Score) > 1e-9 {
+		t.Fatalf("breakdown total %v should equal score %v", total, short.Score)
+	}
+}
+
+func TestScoreTopicSeedSimilarity(t *testing.T) {
+	seed := Seed{Name: "2602.23153", Terms: TermCounts("Streaming 4D reconstruction of dynamic scenes from monocular video")}
+	related := model.Paper{Title: "Dynamic scene reconstruction", Abstract: "Streaming 4D reconstruction from monocular video."}
+	unrelated := model.Paper{Title: "Language agents", Abstract: "Planning with large language model agents."}
+
+	index := NewSimilarityIndex(seed.Terms, TermCounts(related.Title+" "+related.Abstract), TermCounts(unrelated.Title+" "+unrelated.Abstract))
+	corpus := NewCorpus()
+	corpus.SetSeeds("4D", NewSeedSet(index, []Seed{seed}))
+
+	topic := config.Topic{Name: "4D", SeedMode: config.SeedReplace, SeedWeight: 10, Keywords: []config.Keyword{{Word: "agents"}}}
+	relatedResult := ScoreTopic(related, topic, corpus)
+	unrelatedResult := ScoreTopic(unrelated, topic, corpus)
+
+	if relatedResult.Score <= 3 || relatedResult.Score > 10 {
+		t.Fatalf("related paper should score well within seed_weight, got %v", relatedResult.Score)
+	}
+	if unrelatedResult.Score != 0 {
+		t.Fatalf("replace mode should ignore keywords for an unrelated paper, got %v (%#v)", unrelatedResult.Score, unrelatedResult.Hits)
+	}
+
+	topic.SeedMode = config.SeedCombine
+	combined := ScoreTopic(unrelated, topic, corpus)
+	if combined.Score != 2 {
+		t.Fatalf("combine mode should keep keyword score, got %v", combined.Score)
+	}
+}
+
+func TestSeedSimilarityIsExactlyRepeatable(t *testing.T) {
+	t.Parallel()
+
+	seed := Seed{Name: "2602.23153", Terms: TermCounts("Streaming 4D reconstruction of dynamic scenes from monocular video with gaussian splatting, depth priors, camera pose estimation and temporal consistency")}
+	paper := model.Paper{
+		Title:    "Temporally consistent dynamic gaussian splatting",
+		Abstract: "We reconstruct dynamic scenes from casual monocular video, estimating camera pose and depth jointly with a deformation field for streaming novel view synthesis.",
+	}
+	index := NewSimilarityIndex(seed.Terms, TermCounts(paper.Title+" "+paper.Abstract))
+
+	// Float sums depend on their order, so any map-order summing would show
+	// up as last-bit drift across these repetitions.
+	want, _ := NewSeedSet(index, []Seed{seed}).Similarity(paper)
+	for i := 0; i < 50; i++ {
+		if got, _ := NewSeedSet(index, []Seed{seed}).Similarity(paper); got != want {
+			t.Fatalf("similarity drifted between runs: %v then %v", want, got)
+		}
+	}
+}
+
+func TestCitationHits(t *testing.T) {
+	t.Parallel()
+
+	topic := config.Topic{Name: "A", CitationRules: []config.CitationRule{
+		{Field: config.CitationCount, Min: 10, Bonus: 3},
+		{Field: config.CitationInfluential, Min: 5, Bonus: 2},
+		{Field: config.CitationVenue, Match: "cvpr", Bonus: 2},
+		{Field: config.CitationOpenAccess, Bonus: -1},
+	}}
+	hits := CitationHits(topic, model.Citations{Count: 12, Influential: 1, Venue: "CVPR 2026"})
+	if len(hits) != 2 {
+		t.Fatalf("expected the count and venue rules to match, got %#v", hits)
+	}
+	if hits[0].Keyword != "citations >= 10" || hits[0].Points != 3 || hits[0].Field != "citations" || hits[0].Topic != "A" {
+		t.Fatalf("unexpected count hit %#v", hits[0])
+	}
+	if hits[1].Keyword != "venue cvpr" || hits[1].Points != 2 {
+		t.Fatalf("unexpected venue hit %#v", hits[1])
+	}
+
+	hits = CitationHits(topic, model.Citations{OpenAccessPDF: "https://example.org/a.pdf"})
+	if len(hits) != 1 || hits[0].Points != -1 {
+		t.Fatalf("expected the open-access penalty alone, got %#v", hits)
+	}
+}
@@ -0,0 +1,187 @@
+package scoring
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/query"
+)
+
+// stopwords are dropped before similarity vectors are built; they carry no
+// topical signal and would dominate short abstracts.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "can": true, "for": true, "from": true, "has": true, "have": true, "in": true,
+	"into": true, "is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"our": true, "such": true, "that": true, "the": true, "their": true, "these": true,
+	"this": true, "to": true, "we": true, "which": true, "while": true, "with": true,
+	"without": true, "both": true, "than": true, "then": true, "also": true, "not": true,
+	"via": true, "using": true, "based": true, "show": true, "propose": true, "paper": true,
+}
+
+// TermCounts returns the stemmed, stopword-free term frequencies of text,
+// the raw material of a similarity vector.
+func TermCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, token := range tokenize(text) {
+		if stopwords[token] || len([]rune(token)) < 2 || isNumber(token) {
+			continue
+		}
+		counts[stem(token)]++
+	}
+	return counts
+}
+
+func isNumber(token string) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SimilarityIndex holds the document frequencies that weight similarity
+// vectors (TF-IDF over every term, unlike Corpus which tracks keywords).
+type SimilarityIndex struct {
+	docs    int
+	docFreq map[string]int
+}
+
+// NewSimilarityIndex builds document frequencies over the given term counts.
+func NewSimilarityIndex(docs ...map[string]int) *SimilarityIndex {
+	index := &SimilarityIndex{docs: len(docs), docFreq: make(map[string]int)}
+	for _, terms := range docs {
+		for term := range terms {
+			index.docFreq[term]++
+		}
+	}
+	return index
+}
+
+// termWeight is one term of a similarity vector.
+type termWeight struct {
+	term   string
+	weight float64
+}
+
+// vector turns term counts into an L2-normalized TF-IDF vector sorted by
+// term. Summing in term order rather than map order keeps similarity scores
+// identical, to the last bit, from run to run.
+func (x *SimilarityIndex) vector(counts map[string]int) []termWeight {
+	vector := make([]termWeight, 0, len(counts))
+	for term := range counts {
+		vector = append(vector, termWeight{term: term})
+	}
+	slices.SortFunc(vector, func(a, b termWeight) int { return strings.Compare(a.term, b.term) })
+
+	norm := 0.0
+	for i := range vector {
+		idf := math.Log(float64(1+x.docs)/float64(1+x.docFreq[vector[i].term])) + 1
+		vector[i].weight = (1 + math.Log(float64(counts[vector[i].term]))) * idf
+		norm += vector[i].weight * vector[i].weight
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i].weight /= norm
+	}
+	return vector
+}
+
+// dot returns the dot product of two term-sorted vectors.
+func dot(a, b []termWeight) float64 {
+	sum := 0.0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch strings.Compare(a[i].term, b[j].term) {
+		case -1:
+			i++
+		case 1:
+			j++
+		default:
+			sum += a[i].weight * b[j].weight
+			i++
+			j++
+		}
+	}
+	return sum
+}
+
+// Seed is a resolved seed paper: a display name and its term counts.
+type Seed struct {
+	Name  string
+	Terms map[string]int
+}
+
+// SeedSet scores papers by TF-IDF cosine similarity to their nearest seed.
+type SeedSet struct {
+	index   *SimilarityIndex
+	names   []string
+	vectors [][]termWeight
+}
+
+func NewSeedSet(index *SimilarityIndex, seeds []Seed) *SeedSet {
+	set := &SeedSet{index: index}
+	for _, seed := range seeds {
+		set.names = append(set.names, seed.Name)
+		set.vectors = append(set.vectors, index.vector(seed.Terms))
+	}
+	return set
+}
+
+// Similarity returns the cosine similarity, in [0, 1], between paper's
+// title and abstract and its nearest seed, along with that seed's name.
+func (s *SeedSet) Similarity(paper model.Paper) (float64, string) {
+	vector := s.index.vector(TermCounts(paper.Title + " " + paper.Abstract))
+
+	best, nearest := 0.0, ""
+	for i, seed := range s.vectors {
+		if similarity := dot(vector, seed); similarity > best {
+			best, nearest = similarity, s.names[i]
+		}
+	}
+	return best, nearest
+}
+
+// CandidateTerms returns the distinct words and two-word phrases of text
+// that could serve as keywords: lower-cased, unstemmed, with stopwords and
+// numbers left out.
+func CandidateTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	previous := ""
+	for _, token := range tokenize(text) {
+		if stopwords[token] || len([]rune(token)) < 3 || isNumber(token) {
+			previous = ""
+			continue
+		}
+		add(token)
+		if previous != "" {
+			add(previous + " " + token)
+		}
+		previous = token
+	}
+	return terms
+}
+
+// KeywordCounter prepares paper for the given match mode and returns a
+// function counting a keyword's hits in its title and abstract, the same
+// way ScoreTopic does before weights and caps.
+func KeywordCounter(paper model.Paper, match string) func(word string) int {
+	doc := newDocument(paper, match)
+	return func(word string) int {
+		return doc.count(query.FieldAny, word)
+	}
+}
@@ -0,0 +1,140 @@
+package scoring
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize lower-cases text and splits it into word tokens. Letters and
+// digits form tokens; a hyphen joining two token characters is kept, so
+// "memory-efficient" and "training-free" stay single tokens and do not
+// match "memory" or "training" on their own.
+func tokenize(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	tokens := make([]string, 0, len(runes)/6)
+
+	start := -1
+	for i, r := range runes {
+		if isTokenRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if r == '-' && start >= 0 && i+1 < len(runes) && isTokenRune(runes[i+1]) {
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, string(runes[start:i]))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, string(runes[start:]))
+	}
+
+	return tokens
+}
+
+func isTokenRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// stemTokens applies stem to every token in place and returns the slice.
+func stemTokens(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = stem(token)
+	}
+	return tokens
+}
+
+// stem is a light English stemmer: it folds plurals and strips -ing/-ed and
+// a trailing -e so that "videos"/"video", "rendering"/"render" and
+// "images"/"imaging"/"image" meet. Both keywords and paper text go through
+// it, so the stems only need to be consistent, not dictionary words. In a
+// hyphenated token only the last part is stemmed.
+func stem(token string) string {
+	if idx := strings.LastIndex(token, "-"); idx >= 0 {
+		return token[:idx+1] + stem(token[idx+1:])
+	}
+	if len(token) <= 3 || !isASCIILetters(token) {
+		return token
+	}
+
+	switch {
+	case strings.HasSuffix(token, "sses"):
+		token = token[:len(token)-2]
+	case strings.HasSuffix(token, "ies") && len(token) > 4:
+		token = token[:len(token)-3] + "y"
+	case strings.HasSuffix(token, "xes"), strings.HasSuffix(token, "ches"), strings.HasSuffix(token, "shes"):
+		token = token[:len(token)-2]
+	case strings.HasSuffix(token, "s") && !strings.HasSuffix(token, "ss") &&
+		!strings.HasSuffix(token, "us") && !strings.HasSuffix(token, "is"):
+		token = token[:len(token)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed"} {
+		base := strings.TrimSuffix(token, suffix)
+		if base != token && len(base) >= 3 && hasVowel(base) {
+			token = undouble(base)
+			break
+		}
+	}
+
+	if len(token) > 4 && strings.HasSuffix(token, "e") {
+		token = token[:len(token)-1]
+	}
+
+	return token
+}
+
+func undouble(token string) string {
+	n := len(token)
+	if n < 2 || token[n-1] != token[n-2] {
+		return token
+	}
+	switch token[n-1] {
+	case 'l', 's', 'z', 'a', 'e', 'i', 'o', 'u':
+		return token
+	}
+	return token[:n-1]
+}
+
+func hasVowel(token string) bool {
+	return strings.ContainsAny(token, "aeiouy")
+}
+
+func isASCIILetters(token string) bool {
+	for i := 0; i < len(token); i++ {
+		if token[i] < 'a' || token[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// countPhrase counts non-overlapping occurrences of phrase as a run of
+// consecutive tokens.
+func countPhrase(tokens, phrase []string) int {
+	if len(phrase) == 0 || len(phrase) > len(tokens) {
+		return 0
+	}
+
+	count := 0
+	for i := 0; i+len(phrase) <= len(tokens); {
+		matched := true
+		for j, word := range phrase {
+			if tokens[i+j] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+			i += len(phrase)
+			continue
+		}
+		i++
+	}
+	return count
+}
@@ -0,0 +1,180 @@
+// Package seeds resolves a topic's seed_papers (arXiv IDs or local text
+// files) into term vectors for similarity scoring, caching them next to
+// the state file so arXiv is only asked about each seed once.
+package seeds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/model"
+	"github.com/kyc001/paper-radar/internal/scoring"
+)
+
+// CacheFile is the cache's file name inside the state directory.
+const CacheFile = "seeds.json"
+
+// seedIDRe tells arXiv ID seeds from file seeds: a bare new-style
+// (2602.23153) or old-style (hep-th/9901001) ID, optionally prefixed with
+// "arxiv:" and suffixed with a version. Anything else is a file path.
+var seedIDRe = regexp.MustCompile(`^(?i:arxiv:)?([0-9]{4}\.[0-9]{4,5}|[a-z][a-z.-]*/[0-9]{7})(v[0-9]+)?$`)
+
+// Lookup fetches papers by arXiv ID; arxiv.Client satisfies it.
+type Lookup interface {
+	FetchByIDs(ctx context.Context, ids []string) ([]model.Paper, error)
+}
+
+// Entry is one cached seed.
+type Entry struct {
+	Source    string         `json:"source"`
+	Title     string         `json:"title,omitempty"`
+	Hash      string         `json:"hash,omitempty"`
+	Terms     map[string]int `json:"terms"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
+// Cache is the on-disk seed vector cache, keyed by arXiv ID or file path.
+type Cache struct {
+	path    string
+	Entries map[string]Entry `json:"entries"`
+}
+
+// LoadCache reads the cache at path; a missing file yields an empty cache.
+func LoadCache(path string) (*Cache, error) {
+	cache := &Cache{path: path, Entries: map[string]Entry{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cache, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("parse seed cache: %w", err)
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]Entry{}
+	}
+	return cache, nil
+}
+
+func (c *Cache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	tmpFile := c.path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, c.path)
+}
+
+// Resolve returns a seed for every entry. arXiv IDs missing from the cache
+// are looked up in one batch; files are re-read and re-vectorized only when
+// their content changed. Relative file paths are resolved against baseDir.
+func (c *Cache) Resolve(ctx context.Context, lookup Lookup, entries []string, baseDir string) ([]scoring.Seed, error) {
+	keys := make([]string, 0, len(entries))
+	var missing []string
+
+	for _, entry := range entries {
+		if arxivID, ok := model.ParseArxivID(entry); ok && seedIDRe.MatchString(entry) {
+			id := arxivID.Base
+			keys = append(keys, id)
+			if _, ok := c.Entries[id]; !ok {
+				missing = append(missing, id)
+			}
+			continue
+		}
+
+		path := entry
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if err := c.resolveFile(path); err != nil {
+			return nil, fmt.Errorf("seed %q: %w", entry, err)
+		}
+		keys = append(keys, path)
+	}
+
+	if len(missing) > 0 {
+		if err := c.resolveIDs(ctx, lookup, missing); err != nil {
+			return nil, err
+		}
+	}
+
+	seeds := make([]scoring.Seed, 0, len(keys))
+	for _, key := range keys {
+		name := key
+		if c.Entries[key].Source == "file" {
+			name = filepath.Base(key)
+		}
+		seeds = append(seeds, scoring.Seed{Name: name, Terms: c.Entries[key].Terms})
+	}
+	return seeds, nil
+}
+
+func (c *Cache) resolveFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if cached, ok := c.Entries[path]; ok && cached.Hash == hash {
+		return nil
+	}
+
+	c.Entries[path] = Entry{
+		Source:    "file",
+		Hash:      hash,
+		Terms:     scoring.TermCounts(string(data)),
+		FetchedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+func (c *Cache) resolveIDs(ctx context.Context, lookup Lookup, ids []string) error {
+	if lookup == nil {
+		return fmt.Errorf("no arXiv lookup available for seeds %s", strings.Join(ids, ", "))
+	}
+
+	papers, err := lookup.FetchByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("fetch seed papers: %w", err)
+	}
+
+	for _, paper := range papers {
+		id, _ := model.CanonicalID(paper.ID)
+		c.Entries[id] = Entry{
+			Source:    "arxiv",
+			Title:     paper.Title,
+			Terms:     scoring.TermCounts(paper.Title + " " + paper.Abstract),
+			FetchedAt: time.Now().UTC(),
+		}
+	}
+
+	for _, id := range ids {
+		if _, ok := c.Entries[id]; !ok {
+			return fmt.Errorf("seed paper %s not found on arXiv", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package seeds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+type fakeLookup struct {
+	calls [][]string
+}
+
+func (f *fakeLookup) FetchByIDs(_ context.Context, ids []string) ([]model.Paper, error) {
+	f.calls = append(f.calls, ids)
+	papers := make([]model.Paper, 0, len(ids))
+	for _, id := range ids {
+		papers = append(papers, model.Paper{
+			ID:       "http://arxiv.org/abs/" + id + "v2",
+			Title:    "Streaming 4D reconstruction",
+			Abstract: "Training-free streaming reconstruction of dynamic scenes.",
+		})
+	}
+	return papers, nil
+}
+
+func TestResolveCachesArxivAndFileSeeds(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "seed.txt"), []byte("Video memory for long video generation"), 0o644); err != nil {
+		t.Fatalf("write seed file: %v", err)
+	}
+
+	cachePath := filepath.Join(dir, "state", CacheFile)
+	cache, err := LoadCache(cachePath)
+	if err != nil {
+		t.Fatalf("load empty cache: %v", err)
+	}
+
+	lookup := &fakeLookup{}
+	entries := []string{"arxiv:2602.23153v1", "hep-th/9901001", "seed.txt"}
+	seeds, err := cache.Resolve(context.Background(), lookup, entries, dir)
+	if err != nil {
+		t.Fatalf("resolve seeds: %v", err)
+	}
+	if len(seeds) != 3 {
+		t.Fatalf("expected 3 seeds, got %d", len(seeds))
+	}
+	if seeds[0].Name != "2602.23153" || seeds[1].Name != "hep-th/9901001" || seeds[2].Name != "seed.txt" {
+		t.Fatalf("unexpected seed names: %q %q %q", seeds[0].Name, seeds[1].Name, seeds[2].Name)
+	}
+	if seeds[2].Terms["video"] != 2 {
+		t.Fatalf("expected file seed terms, got %#v", seeds[2].Terms)
+	}
+	if len(lookup.calls) != 1 || len(lookup.calls[0]) != 2 {
+		t.Fatalf("expected one batched lookup of 2 IDs, got %#v", lookup.calls)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("save cache: %v", err)
+	}
+
+	reloaded, err := LoadCache(cachePath)
+	if err != nil {
+		t.Fatalf("reload cache: %v", err)
+	}
+	if _, err := reloaded.Resolve(context.Background(), lookup, entries, dir); err != nil {
+		t.Fatalf("resolve from cache: %v", err)
+	}
+	if len(lookup.calls) != 1 {
+		t.Fatalf("cached seeds should not be fetched again, got %d lookups", len(lookup.calls))
+	}
+}
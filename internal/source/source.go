@@ -0,0 +1,164 @@
+// Package source defines how paper-radar talks to a paper source and keeps
+// a registry of the available ones. Source packages register themselves
+// from init; a topic's `source:` names the registration to fetch it with
+// and its `source_options:` block is passed through for the source alone to
+// interpret.
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kyc001/paper-radar/internal/cache"
+	"github.com/kyc001/paper-radar/internal/httpclient"
+	"github.com/kyc001/paper-radar/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// TopicQuery is what a source is asked to fetch for one topic.
+type TopicQuery struct {
+	// Topic is the topic's name, for error messages.
+	Topic string
+	// Query is the topic's query as written in the config; its meaning is
+	// up to the source. It may be empty.
+	Query string
+	// Keywords are the topic's keyword words, for sources that can build
+	// a query from them when Query is empty.
+	Keywords   []string
+	MaxResults int
+	// Since, when non-zero, is the oldest submission date wanted. Only
+	// sources with the DateCutoff capability honour it.
+	Since time.Time
+	// Options is the topic's source_options block.
+	Options Options
+}
+
+// Capabilities tell the pipeline what a source can do beyond Fetch.
+type Capabilities struct {
+	// DateCutoff means Fetch honours TopicQuery.Since.
+	DateCutoff bool
+	// KimiSummary means papers.cool has Kimi summaries for the source's
+	// papers, so topics may ask for kimi_summary.
+	KimiSummary bool
+}
+
+// Source fetches papers for topics.
+type Source interface {
+	// Check validates a topic's query and options before anything is
+	// fetched.
+	Check(q TopicQuery) error
+	Fetch(ctx context.Context, q TopicQuery) ([]model.Paper, error)
+	Capabilities() Capabilities
+}
+
+// Env is what a source may draw on when it is built for a run.
+type Env struct {
+	// HTTP returns the retry policy configured for a source name.
+	HTTP func(name string) httpclient.Policy
+	// Cache is the run's enrichment cache; it may be nil.
+	Cache *cache.Cache
+	// Checkpoints keeps progress between runs; it may be nil.
+	Checkpoints Checkpoints
+}
+
+// Checkpoints keeps a source's progress between runs, such as where an
+// unfinished harvest stopped, under keys the source chooses. Values set
+// during a fetch are saved with the state at its end, together with the
+// papers fetched up to them, and topics fetched concurrently may use it
+// at once.
+type Checkpoints interface {
+	Checkpoint(key string) (string, bool)
+	// SetCheckpoint stores value under key; an empty value deletes it.
+	SetCheckpoint(key, value string)
+}
+
+// Factory builds a source for one run. A run builds each source it uses
+// once and shares it between topics.
+type Factory func(env Env) Source
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a source available under name. It panics on a duplicate
+// name, as that is a programming error.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("source %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered under name.
+func Lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[name]
+	return factory, ok
+}
+
+// Names lists the registered sources, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Options is a topic's free-form source_options block.
+type Options struct {
+	node *yaml.Node
+}
+
+func (o *Options) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: source_options must be a map", node.Line)
+	}
+	o.node = node
+	return nil
+}
+
+// IsZero reports whether the topic set no options.
+func (o Options) IsZero() bool {
+	return o.node == nil || len(o.node.Content) == 0
+}
+
+// Decode decodes the options into v, rejecting fields v does not have.
+// Empty options leave v untouched.
+func (o Options) Decode(v any) error {
+	if o.IsZero() {
+		return nil
+	}
+	data, err := yaml.Marshal(o.node)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("source_options (line %d): %w", o.node.Line, err)
+	}
+	return nil
+}
+
+// NoOptions is a Check helper for sources that take no options.
+func NoOptions(q TopicQuery) error {
+	if !q.Options.IsZero() {
+		return fmt.Errorf("source takes no source_options")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package source
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOptionsDecodeIsStrict(t *testing.T) {
+	t.Parallel()
+
+	var topic struct {
+		Options Options `yaml:"source_options"`
+	}
+	if err := yaml.Unmarshal([]byte("source_options:\n  limit: 3\n  tags: [a, b]\n"), &topic); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	var opts struct {
+		Limit int      `yaml:"limit"`
+		Tags  []string `yaml:"tags"`
+	}
+	if err := topic.Options.Decode(&opts); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if opts.Limit != 3 || len(opts.Tags) != 2 {
+		t.Fatalf("unexpected options %#v", opts)
+	}
+
+	var narrow struct {
+		Limit int `yaml:"limit"`
+	}
+	if err := topic.Options.Decode(&narrow); err == nil || !strings.Contains(err.Error(), "tags") {
+		t.Fatalf("expected an unknown-field error naming tags, got %v", err)
+	}
+	if err := NoOptions(TopicQuery{Options: topic.Options}); err == nil {
+		t.Fatalf("NoOptions should reject a non-empty block")
+	}
+}
+
+func TestOptionsMustBeAMap(t *testing.T) {
+	t.Parallel()
+
+	var topic struct {
+		Options Options `yaml:"source_options"`
+	}
+	if err := yaml.Unmarshal([]byte("source_options: [a]\n"), &topic); err == nil {
+		t.Fatalf("a list should not parse as source_options")
+	}
+	if err := yaml.Unmarshal([]byte("other: 1\n"), &topic); err != nil || !topic.Options.IsZero() {
+		t.Fatalf("missing options should be zero, got %v", err)
+	}
+	if err := topic.Options.Decode(&struct{}{}); err != nil {
+		t.Fatalf("decoding zero options should be a no-op: %v", err)
+	}
+}
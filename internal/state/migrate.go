@@ -0,0 +1,210 @@
+package state
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/kyc001/paper-radar/internal/model"
+)
+
+// CurrentVersion is the state file format written by Save. Load upgrades
+// older files in place; the upgraded form is persisted on the next Save.
+//
+//	0: papers carried a single "summary" holding either the abstract or
+//	   the Kimi Q&A that replaced it
+//	1: papers carry "abstract" and "ai_summary" separately
+//	2: scores are fractional and breakdown hits store their "points"
+//	3: paper IDs are canonical (model.CanonicalID) with the version split
+//	   off, so one paper has one ID whichever source reported it
+//	4: the "seen_ids" set became "seen", recording the version and update
+//	   time last seen per paper
+const CurrentVersion = 4
+
+var aiSummaryRe = regexp.MustCompile(`Q1\s*[:：]`)
+
+// migrate upgrades st, decoded from data, to CurrentVersion.
+func migrate(data []byte, st *FileState) error {
+	if st.Version < 1 {
+		if err := migrateSummaryField(data, st); err != nil {
+			return err
+		}
+	}
+	if st.Version < 2 {
+		migrateHitPoints(st)
+	}
+	if st.Version < 3 {
+		migrateCanonicalIDs(st)
+	}
+	if st.Version < 4 {
+		if err := migrateSeenRecords(data, st); err != nil {
+			return err
+		}
+	}
+
+	st.Version = CurrentVersion
+	return nil
+}
+
+// migrateSummaryField moves the legacy pending "summary" into Abstract, or
+// into AISummary when it is a Kimi Q&A (the original abstract is lost then).
+func migrateSummaryField(data []byte, st *FileState) error {
+	var legacy struct {
+		Pending []struct {
+			Paper struct {
+				Summary string `json:"summary"`
+			} `json:"paper"`
+		} `json:"pending"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+
+	for i := range st.Pending {
+		if i >= len(legacy.Pending) {
+			break
+		}
+		summary := legacy.Pending[i].Paper.Summary
+		paper := &st.Pending[i].Paper
+		if summary == "" || paper.Abstract != "" || paper.AISummary != "" {
+			continue
+		}
+		if aiSummaryRe.MatchString(summary) {
+			paper.AISummary = summary
+		} else {
+			paper.Abstract = summary
+		}
+	}
+
+	return nil
+}
+
+// migrateHitPoints fills in Points for breakdown hits recorded before they
+// were stored, when points were always Hits × Weight × FieldWeight.
+func migrateHitPoints(st *FileState) {
+	for i := range st.Pending {
+		for j := range st.Pending[i].Breakdown {
+			hit := &st.Pending[i].Breakdown[j]
+			if hit.Points != 0 {
+				continue
+			}
+			fieldWeight := hit.FieldWeight
+			if fieldWeight == 0 {
+				fieldWeight = 1
+			}
+			hit.Points = float64(hit.Hits * hit.Weight * fieldWeight)
+		}
+	}
+}
+
+// migrateCanonicalIDs rewrites every stored paper ID to its canonical form.
+// Pending papers that were queued twice under different source IDs are
+// merged: the higher-scored copy is kept and gains the other copy's topics,
+// with their breakdown and points. Feedback keeps the latest label per paper.
+// Seen IDs are canonicalized by migrateSeenRecords.
+func migrateCanonicalIDs(st *FileState) {
+	for i := range st.Digested {
+		canonicalizePaper(&st.Digested[i].Paper)
+	}
+	for i := range st.Vetoed {
+		st.Vetoed[i].ID, _ = model.CanonicalID(st.Vetoed[i].ID)
+	}
+
+	var feedback []model.Feedback
+	index := make(map[string]int)
+	for _, record := range st.Feedback {
+		record.ID, _ = model.CanonicalID(record.ID)
+		if i, ok := index[record.ID]; ok {
+			if record.At.After(feedback[i].At) {
+				feedback[i] = record
+			}
+			continue
+		}
+		index[record.ID] = len(feedback)
+		feedback = append(feedback, record)
+	}
+	st.Feedback = feedback
+
+	var pending []model.ScoredPaper
+	position := make(map[string]int)
+	for _, paper := range st.Pending {
+		canonicalizePaper(&paper.Paper)
+		i, ok := position[paper.Paper.ID]
+		if !ok {
+			position[paper.Paper.ID] = len(pending)
+			pending = append(pending, paper)
+			continue
+		}
+		if paper.Score > pending[i].Score {
+			paper, pending[i] = pending[i], paper
+		}
+		pending[i] = mergeTopics(pending[i], paper)
+	}
+	st.Pending = pending
+}
+
+func canonicalizePaper(paper *model.Paper) {
+	id, version := model.CanonicalID(paper.ID)
+	paper.ID = id
+	if paper.Version == 0 {
+		paper.Version = version
+	}
+}
+
+// mergeTopics adds other's topics that kept lacks, with their hits and
+// points, and fills in the AI summary and version where kept has none.
+func mergeTopics(kept, other model.ScoredPaper) model.ScoredPaper {
+	has := make(map[string]bool, len(kept.Topics))
+	for _, topic := range kept.Topics {
+		has[topic] = true
+	}
+	for _, hit := range other.Breakdown {
+		if !has[hit.Topic] {
+			kept.Breakdown = append(kept.Breakdown, hit)
+			kept.Score += hit.Points
+		}
+	}
+	for _, topic := range other.Topics {
+		if !has[topic] {
+			kept.Topics = append(kept.Topics, topic)
+			has[topic] = true
+		}
+	}
+	if kept.Paper.AISummary == "" {
+		kept.Paper.AISummary = other.Paper.AISummary
+	}
+	if other.Paper.Version > kept.Paper.Version {
+		kept.Paper.Version = other.Paper.Version
+	}
+	return kept
+}
+
+// migrateSeenRecords turns the legacy "seen_ids" set into seen records.
+// Versions are only known for papers still in pending or the digest
+// history; the rest start with an unknown baseline, which the next fetch
+// that sees them fills in.
+func migrateSeenRecords(data []byte, st *FileState) error {
+	var legacy struct {
+		SeenIDs map[string]bool `json:"seen_ids"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+
+	if st.Seen == nil {
+		st.Seen = make(map[string]model.SeenPaper, len(legacy.SeenIDs))
+	}
+	for id, ok := range legacy.SeenIDs {
+		if ok {
+			canonical, _ := model.CanonicalID(id)
+			st.Seen[canonical] = st.Seen[canonical]
+		}
+	}
+	for _, papers := range [][]model.ScoredPaper{st.Digested, st.Pending} {
+		for _, paper := range papers {
+			if seen, ok := st.Seen[paper.Paper.ID]; ok {
+				st.Seen[paper.Paper.ID] = seen.Track(paper.Paper)
+			}
+		}
+	}
+	return nil
+}
@@ -11,8 +11,20 @@ import (
 )
 
 type FileState struct {
-	SeenIDs map[string]bool     `json:"seen_ids"`
-	Pending []model.ScoredPaper `json:"pending"`
+	Version int                        `json:"version"`
+	Seen    map[string]model.SeenPaper `json:"seen"`
+	Pending []model.ScoredPaper        `json:"pending"`
+	Vetoed  []model.VetoRecord         `json:"vetoed,omitempty"`
+	Corpus  []model.CorpusStats        `json:"corpus,omitempty"`
+
+	// Digested is a rolling history of papers already sent out in a digest,
+	// kept so feedback can refer to them by ID.
+	Digested []model.ScoredPaper `json:"digested,omitempty"`
+	Feedback []model.Feedback    `json:"feedback,omitempty"`
+
+	// Checkpoints are the sources' progress between runs (see
+	// source.Checkpoints), opaque to the state.
+	Checkpoints map[string]string `json:"checkpoints,omitempty"`
 }
 
 type Store struct {
@@ -23,6 +35,11 @@ func New(path string) *Store {
 	return &Store{path: path}
 }
 
+// Path is the state file location; sibling caches live in its directory.
+func (s *Store) Path() string {
+	return s.path
+}
+
 func (s *Store) Load() (FileState, error) {
 	data, err := os.ReadFile(s.path)
 	if err != nil {
@@ -36,9 +53,12 @@ func (s *Store) Load() (FileState, error) {
 	if err := json.Unmarshal(data, &st); err != nil {
 		return FileState{}, err
 	}
+	if err := migrate(data, &st); err != nil {
+		return FileState{}, fmt.Errorf("migrate state: %w", err)
+	}
 
-	if st.SeenIDs == nil {
-		st.SeenIDs = map[string]bool{}
+	if st.Seen == nil {
+		st.Seen = map[string]model.SeenPaper{}
 	}
 	if st.Pending == nil {
 		st.Pending = []model.ScoredPaper{}
@@ -48,8 +68,9 @@ func (s *Store) Load() (FileState, error) {
 }
 
 func (s *Store) Save(st FileState) error {
-	if st.SeenIDs == nil {
-		st.SeenIDs = map[string]bool{}
+	st.Version = CurrentVersion
+	if st.Seen == nil {
+		st.Seen = map[string]model.SeenPaper{}
 	}
 	if st.Pending == nil {
 		st.Pending = []model.ScoredPaper{}
@@ -78,7 +99,8 @@ func (s *Store) Save(st FileState) error {
 
 func emptyState() FileState {
 	return FileState{
-		SeenIDs: map[string]bool{},
+		Version: CurrentVersion,
+		Seen:    map[string]model.SeenPaper{},
 		Pending: []model.ScoredPaper{},
 	}
 }
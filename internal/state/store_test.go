@@ -0,0 +1,98 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMigratesLegacySummary(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	legacy := `{
+  "seen_ids": {"a": true, "b": true},
+  "pending": [
+    {"paper": {"id": "a", "title": "A", "summary": "We propose a training-free method."}, "score": 3, "topics": ["t"],
+     "breakdown": [{"topic": "t", "keyword": "training-free", "field": "abstract", "hits": 1, "weight": 3}]},
+    {"paper": {"id": "b", "title": "B", "summary": "Q1: 这篇论文试图解决什么问题？ ..."}, "score": 2, "topics": ["t"]}
+  ]
+}
+`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("write legacy state: %v", err)
+	}
+
+	store := New(path)
+	st, err := store.Load()
+	if err != nil {
+		t.Fatalf("load legacy state: %v", err)
+	}
+	if st.Version != CurrentVersion {
+		t.Fatalf("expected version %d, got %d", CurrentVersion, st.Version)
+	}
+	if got := st.Pending[0].Paper; got.Abstract != "We propose a training-free method." || got.AISummary != "" {
+		t.Fatalf("plain summary should migrate to abstract, got %#v", got)
+	}
+	if got := st.Pending[1].Paper; got.Abstract != "" || got.AISummary == "" {
+		t.Fatalf("kimi summary should migrate to ai_summary, got %#v", got)
+	}
+	if got := st.Pending[0].Breakdown[0].Points; got != 3 {
+		t.Fatalf("breakdown points should be derived from hits and weight, got %v", got)
+	}
+
+	if err := store.Save(st); err != nil {
+		t.Fatalf("save migrated state: %v", err)
+	}
+	again, err := store.Load()
+	if err != nil {
+		t.Fatalf("reload migrated state: %v", err)
+	}
+	if again.Pending[0].Paper.Abstract != st.Pending[0].Paper.Abstract {
+		t.Fatalf("migrated abstract should round-trip, got %#v", again.Pending[0].Paper)
+	}
+}
+
+func TestLoadMigratesCanonicalIDs(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	v2 := `{
+  "version": 2,
+  "seen_ids": {"http://arxiv.org/abs/2602.23153v1": true, "https://papers.cool/arxiv/2602.23153": true, "http://arxiv.org/abs/hep-th/9901001v2": true},
+  "pending": [
+    {"paper": {"id": "http://arxiv.org/abs/2602.23153v1", "title": "A", "abstract": "a"}, "score": 2, "topics": ["t1"],
+     "breakdown": [{"topic": "t1", "keyword": "a", "field": "title", "hits": 1, "weight": 2, "points": 2}]},
+    {"paper": {"id": "https://papers.cool/arxiv/2602.23153", "title": "A", "abstract": "a", "ai_summary": "Q1: ..."}, "score": 5, "topics": ["t1", "t2"],
+     "breakdown": [{"topic": "t1", "keyword": "a", "field": "title", "hits": 1, "weight": 1, "points": 1},
+                   {"topic": "t2", "keyword": "b", "field": "title", "hits": 1, "weight": 4, "points": 4}]},
+    {"paper": {"id": "http://arxiv.org/abs/hep-th/9901001v2", "title": "B", "abstract": "b"}, "score": 1, "topics": ["t1"]}
+  ],
+  "vetoed": [{"id": "http://arxiv.org/abs/2602.00001v3", "title": "C", "topic": "t1", "reason": "r", "score": 1}]
+}
+`
+	if err := os.WriteFile(path, []byte(v2), 0o644); err != nil {
+		t.Fatalf("write v2 state: %v", err)
+	}
+
+	st, err := New(path).Load()
+	if err != nil {
+		t.Fatalf("load v2 state: %v", err)
+	}
+	if len(st.Seen) != 2 || st.Seen["2602.23153"].Version != 1 || st.Seen["hep-th/9901001"].Version != 2 {
+		t.Fatalf("expected canonical seen IDs with pending versions, got %v", st.Seen)
+	}
+	if len(st.Pending) != 2 {
+		t.Fatalf("expected the two copies of 2602.23153 to merge, got %#v", st.Pending)
+	}
+	merged := st.Pending[0]
+	if merged.Paper.ID != "2602.23153" || merged.Score != 5 || len(merged.Topics) != 2 || merged.Paper.AISummary == "" {
+		t.Fatalf("expected the higher-scored copy to be kept, got %#v", merged)
+	}
+	if got := st.Pending[1].Paper; got.ID != "hep-th/9901001" || got.Version != 2 {
+		t.Fatalf("expected old-style ID with version split off, got %#v", got)
+	}
+	if st.Vetoed[0].ID != "2602.00001" {
+		t.Fatalf("expected canonical veto ID, got %q", st.Vetoed[0].ID)
+	}
+}